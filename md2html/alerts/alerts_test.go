@@ -1,11 +1,14 @@
 package alerts
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/testutil"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
 )
 
 func TestAlertBlock(t *testing.T) {
@@ -19,3 +22,56 @@ func TestAlertBlock(t *testing.T) {
 	)
 	testutil.DoTestCaseFile(markdown, "_test/alert_block.txt", t, testutil.ParseCliCaseArg()...)
 }
+
+func TestAlertUnknownReport(t *testing.T) {
+	diags := []*diag.Diagnostic{}
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewAlertBlock(WithReport(func(d *diag.Diagnostic) {
+				diags = append(diags, d)
+			})),
+		),
+	)
+
+	var buf bytes.Buffer
+	src := []byte("# title\n\n> [!NOTE]\n> ok\n\n> [!BOGUS]\n> text\n")
+	if err := markdown.Convert(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diags))
+	}
+	if d := diags[0]; d.Line != 6 || d.Column != 1 || d.Severity != diag.Warning {
+		t.Errorf("got %s", d)
+	}
+}
+
+func TestAlertCustomTypes(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewAlertBlock(WithAlertTypes(AlertTypes{
+				"FAQ": {Icon: "?", Title: "FAQ", Class: "callout-blue", Fold: FoldClosed},
+			})),
+		),
+	)
+
+	var buf bytes.Buffer
+	src := []byte("> [!FAQ]\n> answer\n\n> [!FAQ]+ Why\n> because\n")
+	if err := markdown.Convert(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<details class="markdown-alert markdown-alert-FAQ callout-blue">
+<summary class="markdown-alert_title markdown-alert_title-FAQ">? FAQ</summary>
+<p>answer</p>
+</details>
+<details class="markdown-alert markdown-alert-FAQ callout-blue" open="">
+<summary class="markdown-alert_title markdown-alert_title-FAQ">? Why</summary>
+<p>because</p>
+</details>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -7,6 +7,7 @@ import (
 type AlertBlockNode struct {
 	ast.BaseBlock
 	Label []byte
+	Fold  string
 }
 
 func (n *AlertBlockNode) Dump(source []byte, level int) {
@@ -19,12 +20,17 @@ func (n *AlertBlockNode) Kind() ast.NodeKind {
 	return KindAlertBlock
 }
 
-func NewAlertBlockNode(label []byte) *AlertBlockNode {
+func NewAlertBlockNode(label []byte, class string, fold string) *AlertBlockNode {
 	n := &AlertBlockNode{
 		BaseBlock: ast.BaseBlock{},
 		Label: label,
+		Fold:  fold,
 	}
-	n.SetAttributeString("class", "markdown-alert markdown-alert-"+string(label))
+	cls := "markdown-alert markdown-alert-" + string(label)
+	if class != "" {
+		cls += " " + class
+	}
+	n.SetAttributeString("class", cls)
 	return n
 }
 
@@ -1,26 +1,60 @@
 package alerts
 
 import (
+	"strings"
+
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/util"
 
+	"github.com/1f408/cats_eeds/md2html/diag"
 	"github.com/1f408/cats_eeds/md2html/htfix"
 )
 
 type TitleHtmlMapping map[string]string
+
+const (
+	FoldNone   = ""
+	FoldOpen   = "open"
+	FoldClosed = "closed"
+)
+
+// An AlertType is the title HTML, CSS class and default fold state of an alert type.
+type AlertType struct {
+	Icon  string
+	Title string
+	Class string
+	Fold  string
+}
+
+type AlertTypes map[string]*AlertType
+
+// Lookup returns the alert type of label, matched case-insensitively.
+func (at AlertTypes) Lookup(label string) (string, *AlertType, bool) {
+	if t, ok := at[label]; ok {
+		return label, t, true
+	}
+	for k, t := range at {
+		if strings.EqualFold(k, label) {
+			return k, t, true
+		}
+	}
+	return "", nil, false
+}
+
 type Config struct {
-	TitleHtmlMapping TitleHtmlMapping
+	Types  AlertTypes
+	Report diag.ReportFunc
 }
 
 var DefaultConfig = Config{
-	TitleHtmlMapping: TitleHtmlMapping{
-		"NOTE":      htfix.FixHTMLString(`🔍 Note`),
-		"TIP":       htfix.FixHTMLString(`💡 Tip`),
-		"IMPORTANT": htfix.FixHTMLString(`🔔 Important`),
-		"WARNING":   htfix.FixHTMLString(`📣 Warning`),
-		"CAUTION":   htfix.FixHTMLString(`💣 Caution`),
+	Types: AlertTypes{
+		"NOTE":      {Icon: htfix.FixHTMLString(`🔍`), Title: htfix.FixHTMLString(`Note`)},
+		"TIP":       {Icon: htfix.FixHTMLString(`💡`), Title: htfix.FixHTMLString(`Tip`)},
+		"IMPORTANT": {Icon: htfix.FixHTMLString(`🔔`), Title: htfix.FixHTMLString(`Important`)},
+		"WARNING":   {Icon: htfix.FixHTMLString(`📣`), Title: htfix.FixHTMLString(`Warning`)},
+		"CAUTION":   {Icon: htfix.FixHTMLString(`💣`), Title: htfix.FixHTMLString(`Caution`)},
 	},
 }
 
@@ -28,20 +62,45 @@ type Option interface {
 	SetAlertBlockOption(*Config)
 }
 
-type withTitleMapping struct {
-	value TitleHtmlMapping
+type withAlertTypes struct {
+	value AlertTypes
 }
 
-func (o *withTitleMapping) SetAlertBlockOption(c *Config) {
-	c.TitleHtmlMapping = o.value
+func (o *withAlertTypes) SetAlertBlockOption(c *Config) {
+	c.Types = o.value
 }
 
 func WithTitleHtmlMaping(v TitleHtmlMapping) Option {
-	mp := make(TitleHtmlMapping, len(v))
+	types := make(AlertTypes, len(v))
 	for k, v := range v {
-		mp[k] = htfix.FixHTMLString(v)
+		types[k] = &AlertType{Title: htfix.FixHTMLString(v)}
 	}
-	return &withTitleMapping{value: mp}
+	return &withAlertTypes{value: types}
+}
+
+func WithAlertTypes(v AlertTypes) Option {
+	types := make(AlertTypes, len(v))
+	for k, v := range v {
+		types[k] = &AlertType{
+			Icon:  htfix.FixHTMLString(v.Icon),
+			Title: htfix.FixHTMLString(v.Title),
+			Class: v.Class,
+			Fold:  v.Fold,
+		}
+	}
+	return &withAlertTypes{value: types}
+}
+
+type withReport struct {
+	value diag.ReportFunc
+}
+
+func (o *withReport) SetAlertBlockOption(c *Config) {
+	c.Report = o.value
+}
+
+func WithReport(f diag.ReportFunc) Option {
+	return &withReport{value: f}
 }
 
 func NewAlertBlock(opts ...Option) goldmark.Extender {
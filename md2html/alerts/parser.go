@@ -7,6 +7,8 @@ import (
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
 )
 
 type alertBlockParser struct {
@@ -27,10 +29,10 @@ func (b *alertBlockParser) Trigger() []byte {
 	return []byte{'>'}
 }
 
-var alertBlockRegexp = regexp.MustCompile(`^>\s*\[!(\w+)\]\s*$`)
+var alertBlockRegexp = regexp.MustCompile(`^>\s*\[!(\w+)\]([-+]?)(?:[ \t]+(.*?))?\s*$`)
 
 func (b *alertBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
-	line, _ := reader.PeekLine()
+	line, seg := reader.PeekLine()
 	w, pos := util.IndentWidth(line, reader.LineOffset())
 	if w > 3 || pos >= len(line) || line[pos] != '>' {
 		return nil, parser.NoChildren
@@ -46,18 +48,46 @@ func (b *alertBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.C
 		return nil, parser.NoChildren
 	}
 
-	lbl := line[m[2]:m[3]]
-	if _, ok := b.Config.TitleHtmlMapping[string(lbl)]; !ok {
+	lbl := line[pos+m[2] : pos+m[3]]
+	key, typ, ok := b.Config.Types.Lookup(string(lbl))
+	if !ok {
+		b.reportUnknown(lbl, reader.Source(), seg.Start+pos)
 		return nil, parser.NoChildren
 	}
 
-	an := NewAlertBlockNode(lbl)
-	an.AppendChild(an, NewAlertTitleNode(lbl))
+	fold := typ.Fold
+	switch string(line[pos+m[4] : pos+m[5]]) {
+	case "-":
+		fold = FoldClosed
+	case "+":
+		fold = FoldOpen
+	}
+
+	an := NewAlertBlockNode([]byte(key), typ.Class, fold)
+	tn := NewAlertTitleNode([]byte(key))
+	if m[6] >= 0 && m[7] > m[6] {
+		tn.Lines().Append(text.NewSegment(seg.Start+pos+m[6], seg.Start+pos+m[7]))
+	}
+	an.AppendChild(an, tn)
 
 	reader.Advance(pos + 1)
 	return an, parser.NoChildren
 }
 
+func (b *alertBlockParser) reportUnknown(lbl []byte, src []byte, offset int) {
+	if b.Config.Report == nil {
+		return
+	}
+
+	line, col := diag.Position(src, offset)
+	b.Config.Report(&diag.Diagnostic{
+		Severity: diag.Warning,
+		Line:     line,
+		Column:   col,
+		Message:  "unknown alert type: [!" + string(lbl) + "]",
+	})
+}
+
 func (b *alertBlockParser) read_quote(reader text.Reader) bool {
 	line, _ := reader.PeekLine()
 	w, pos := util.IndentWidth(line, reader.LineOffset())
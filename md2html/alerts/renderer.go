@@ -29,16 +29,21 @@ func (r *alertBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegister
 
 func (r *alertBlockRenderer) renderAlertBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	n := node.(*AlertBlockNode)
+	tag := "div"
+	if n.Fold != FoldNone {
+		tag = "details"
+	}
 	if entering {
+		_, _ = w.WriteString("<" + tag)
 		if n.Attributes() != nil {
-			_, _ = w.WriteString("<div")
 			html.RenderAttributes(w, n, AlertBlockAttributeFilter)
-			_, _ = w.WriteString(">\n")
-		} else {
-			_, _ = w.WriteString("<div>\n")
 		}
+		if n.Fold == FoldOpen {
+			_, _ = w.WriteString(` open=""`)
+		}
+		_, _ = w.WriteString(">\n")
 	} else {
-		_, _ = w.WriteString("</div>\n")
+		_, _ = w.WriteString("</" + tag + ">\n")
 	}
 	return ast.WalkContinue, nil
 }
@@ -65,19 +70,27 @@ func (r *alertTitleRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegister
 
 func (r *alertTitleRenderer) renderAlertTitle(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	n := node.(*AlertTitleNode)
+	tag := "p"
+	if an, ok := n.Parent().(*AlertBlockNode); ok && an.Fold != FoldNone {
+		tag = "summary"
+	}
 	if entering {
+		_, _ = w.WriteString("<" + tag)
 		if n.Attributes() != nil {
-			_, _ = w.WriteString("<p")
 			html.RenderAttributes(w, n, AlertTitleAttributeFilter)
-			_ = w.WriteByte('>')
-		} else {
-			_, _ = w.WriteString("<p>")
 		}
-		if hc, ok := r.Config.TitleHtmlMapping[string(n.Label)]; ok {
-			_, _ = w.WriteString(hc)
+		_ = w.WriteByte('>')
+		if typ, ok := r.Config.Types[string(n.Label)]; ok {
+			if typ.Icon != "" {
+				_, _ = w.WriteString(typ.Icon)
+				_ = w.WriteByte(' ')
+			}
+			if !n.HasChildren() {
+				_, _ = w.WriteString(typ.Title)
+			}
 		}
 	} else {
-		_, _ = w.WriteString("</p>\n")
+		_, _ = w.WriteString("</" + tag + ">\n")
 	}
 	return ast.WalkContinue, nil
 }
@@ -2,6 +2,7 @@ package md2html
 
 import (
 	_ "embed"
+	"fmt"
 
 	"github.com/naoina/toml"
 
@@ -11,7 +12,38 @@ import (
 //go:embed "alert_title_mapping.conf"
 var defaultAlertTitleMapping []byte
 
-type AlertTitleMapping alerts.TitleHtmlMapping
+// AlertTypeConfig is an alert type entry, a table or a plain title HTML string.
+type AlertTypeConfig alerts.AlertType
+
+func (atc *AlertTypeConfig) UnmarshalTOML(decode func(interface{}) error) error {
+	var title string
+	if err := decode(&title); err == nil {
+		*atc = AlertTypeConfig{Title: title}
+		return nil
+	}
+
+	type Raw AlertTypeConfig
+	if err := decode((*Raw)(atc)); err != nil {
+		return err
+	}
+
+	switch atc.Fold {
+	case alerts.FoldNone, alerts.FoldOpen, alerts.FoldClosed:
+	default:
+		return fmt.Errorf("No suppoted 'fold' value: %s", atc.Fold)
+	}
+	return nil
+}
+
+type AlertTitleMapping map[string]*AlertTypeConfig
+
+func (thm AlertTitleMapping) AlertTypes() alerts.AlertTypes {
+	types := make(alerts.AlertTypes, len(thm))
+	for k, v := range thm {
+		types[k] = (*alerts.AlertType)(v)
+	}
+	return types
+}
 
 func (ppm *AlertTitleMapping) Initialize() {
 	type Raw AlertTitleMapping
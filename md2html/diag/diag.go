@@ -0,0 +1,108 @@
+package diag
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "unknown"
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (d *Diagnostic) String() string {
+	pos := d.File
+	if d.Line > 0 {
+		pos += fmt.Sprintf(":%d:%d", d.Line, d.Column)
+	}
+	return pos + ": " + d.Severity.String() + ": " + d.Message
+}
+
+type ReportFunc func(*Diagnostic)
+
+// Position returns the 1-based line and column (in runes) of offset in src.
+func Position(src []byte, offset int) (int, int) {
+	if offset < 0 || offset > len(src) {
+		return 0, 0
+	}
+
+	head := src[:offset]
+	line := bytes.Count(head, []byte("\n")) + 1
+	if nl := bytes.LastIndexByte(head, '\n'); nl >= 0 {
+		head = head[nl+1:]
+	}
+
+	return line, utf8.RuneCount(head) + 1
+}
+
+type List struct {
+	mtx     sync.Mutex
+	items   []*Diagnostic
+	offsets map[string]int
+}
+
+func NewList() *List {
+	return &List{items: []*Diagnostic{}, offsets: map[string]int{}}
+}
+
+// SetLineOffset sets the number of the lines cut off in front of the
+// converted text of file, such as the front matter, and returns the
+// previous one. The lines of the reported diagnostics are shifted by it.
+func (l *List) SetLineOffset(file string, n int) int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	prev := l.offsets[file]
+	if n == 0 {
+		delete(l.offsets, file)
+	} else {
+		l.offsets[file] = n
+	}
+	return prev
+}
+
+func (l *List) Report(d *Diagnostic) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if d.Line > 0 {
+		d.Line += l.offsets[d.File]
+	}
+	l.items = append(l.items, d)
+}
+
+func (l *List) Items() []*Diagnostic {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return append([]*Diagnostic{}, l.items...)
+}
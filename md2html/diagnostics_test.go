@@ -0,0 +1,50 @@
+package md2html
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestDiagnosticsFrontMatterLine(t *testing.T) {
+	fsys := fstest.MapFS{
+		"md.conf":     {Data: []byte("[extension]\nms_include = true\n")},
+		"doc/part.md": {Data: []byte("---\nproduct: cats\n---\n\n[!INCLUDE [a](missing_a.md)]\n")},
+	}
+	md_cfg, err := NewMdConfig(fsys, "/md.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fm_cfg := FrontMatterConfig{Yaml: true}
+	raw_bin := []byte("---\nproduct: cats\ntitle: index\n---\ntext\n\n[!INCLUDE [b](missing_b.md)]\n\n[!INCLUDE [part](part.md)]\n")
+	md_bin, fm_param, err := fm_cfg.TrimAndParse(raw_bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fm_param.LineOffset != 4 {
+		t.Fatalf("LineOffset: got %d, want 4", fm_param.LineOffset)
+	}
+
+	m2h := NewMd2Html(&Md2HtmlConfig{
+		MdConfig:     md_cfg,
+		SystemFS:     fsys,
+		FrontMatter:  fm_cfg,
+		StartMdFile:  "/doc/index.md",
+		DocumentRoot: "/doc",
+		LineOffset:   fm_param.LineOffset,
+	})
+	res, err := m2h.ConvertWithDiagnostics(md_bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"/doc/index.md": 7, "/doc/part.md": 5}
+	if len(res.Diagnostics) != len(want) {
+		t.Fatalf("got %d diagnostics: %v", len(res.Diagnostics), res.Diagnostics)
+	}
+	for _, d := range res.Diagnostics {
+		if d.Line != want[d.File] || d.Column != 1 {
+			t.Errorf("%s: got %d:%d, want %d:1", d, d.Line, d.Column, want[d.File])
+		}
+	}
+}
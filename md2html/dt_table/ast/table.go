@@ -77,6 +77,62 @@ func NewTable() *Table {
 	}
 }
 
+// Caption returns the caption of the table, or nil.
+func (n *Table) Caption() *TableCaption {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if cap, ok := c.(*TableCaption); ok {
+			return cap
+		}
+	}
+	return nil
+}
+
+// A TableCaption struct represents a table caption.
+type TableCaption struct {
+	gast.BaseBlock
+}
+
+// Dump implements Node.Dump.
+func (n *TableCaption) Dump(source []byte, level int) {
+	gast.DumpHelper(n, source, level, nil, nil)
+}
+
+// KindTableCaption is a NodeKind of the TableCaption node.
+var KindTableCaption = gast.NewNodeKind("TableCaption")
+
+// Kind implements Node.Kind.
+func (n *TableCaption) Kind() gast.NodeKind {
+	return KindTableCaption
+}
+
+// NewTableCaption returns a new TableCaption node.
+func NewTableCaption() *TableCaption {
+	return &TableCaption{}
+}
+
+// A TableFooter struct represents footer rows of a table.
+type TableFooter struct {
+	gast.BaseBlock
+}
+
+// Dump implements Node.Dump.
+func (n *TableFooter) Dump(source []byte, level int) {
+	gast.DumpHelper(n, source, level, nil, nil)
+}
+
+// KindTableFooter is a NodeKind of the TableFooter node.
+var KindTableFooter = gast.NewNodeKind("TableFooter")
+
+// Kind implements Node.Kind.
+func (n *TableFooter) Kind() gast.NodeKind {
+	return KindTableFooter
+}
+
+// NewTableFooter returns a new TableFooter node.
+func NewTableFooter() *TableFooter {
+	return &TableFooter{}
+}
+
 // A TableRow struct represents a table row of Markdown(GFM) text.
 type TableRow struct {
 	gast.BaseBlock
@@ -137,11 +193,22 @@ type TableCell struct {
 	gast.BaseBlock
 	Alignment Alignment
 	IsHeader bool
+
+	// ColSpan and RowSpan are numbers of columns and rows the cell spans.
+	ColSpan int
+	RowSpan int
 }
 
 // Dump implements Node.Dump.
 func (n *TableCell) Dump(source []byte, level int) {
-	gast.DumpHelper(n, source, level, nil, nil)
+	kv := map[string]string{}
+	if n.ColSpan > 1 {
+		kv["ColSpan"] = fmt.Sprint(n.ColSpan)
+	}
+	if n.RowSpan > 1 {
+		kv["RowSpan"] = fmt.Sprint(n.RowSpan)
+	}
+	gast.DumpHelper(n, source, level, kv, nil)
 }
 
 // KindTableCell is a NodeKind of the TableCell node.
@@ -156,5 +223,7 @@ func (n *TableCell) Kind() gast.NodeKind {
 func NewTableCell() *TableCell {
 	return &TableCell{
 		Alignment: AlignNone,
+		ColSpan:   1,
+		RowSpan:   1,
 	}
 }
@@ -0,0 +1,102 @@
+package dt_table
+
+import (
+	"bytes"
+
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/dt_table/ast"
+)
+
+var captionPrefix = []byte("Table:")
+
+// parseCaptionLine parses a "Table: caption {#id}" line.
+func parseCaptionLine(seg text.Segment, source []byte) (text.Segment, parser.Attributes, bool) {
+	seg = seg.TrimLeftSpace(source)
+	seg = seg.TrimRightSpace(source)
+	if !bytes.HasPrefix(seg.Value(source), captionPrefix) {
+		return seg, nil, false
+	}
+	seg = seg.WithStart(seg.Start + len(captionPrefix))
+	seg = seg.TrimLeftSpace(source)
+
+	var attrs parser.Attributes
+	line := seg.Value(source)
+	if len(line) > 0 && line[len(line)-1] == '}' {
+		if i := bytes.LastIndexByte(line, '{'); i >= 0 {
+			if a, ok := parser.ParseAttributes(text.NewReader(line[i:])); ok {
+				attrs = a
+				seg = seg.WithStop(seg.Start + i)
+				seg = seg.TrimRightSpace(source)
+			}
+		}
+	}
+	if seg.IsEmpty() {
+		return seg, nil, false
+	}
+
+	return seg, attrs, true
+}
+
+func isFooterDelim(bs []byte) bool {
+	if w, _ := util.IndentWidth(bs, 0); w > 3 {
+		return false
+	}
+	has_eq := false
+	for _, b := range bs {
+		if b == '=' {
+			has_eq = true
+		}
+		if !(util.IsSpace(b) || b == '=' || b == '|' || b == ':') {
+			return false
+		}
+	}
+	return has_eq
+}
+
+// setTableCaption adds a caption to the table and gives the table an id,
+// registered in the document IDs, for cross references.
+func setTableCaption(table *ast.Table, cap *ast.TableCaption, cap_text []byte,
+	attrs parser.Attributes, pc parser.Context) {
+	for _, a := range attrs {
+		if string(a.Name) == "id" {
+			if id, ok := a.Value.([]byte); ok {
+				pc.IDs().Put(id)
+			}
+		}
+		table.SetAttribute(a.Name, a.Value)
+	}
+	if _, ok := table.AttributeString("id"); !ok {
+		id := pc.IDs().Generate(append([]byte("table-"), cap_text...), ast.KindTable)
+		table.SetAttributeString("id", id)
+	}
+
+	table.InsertBefore(table, table.FirstChild(), cap)
+}
+
+// tableSections returns the rows of each table section (header, body, footer).
+func tableSections(table *ast.Table) [][]gast.Node {
+	sections := [][]gast.Node{}
+	var body []gast.Node
+	for c := table.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c.Kind() {
+		case ast.KindTableHeader:
+			sections = append(sections, []gast.Node{c})
+		case ast.KindTableRow:
+			body = append(body, c)
+		case ast.KindTableFooter:
+			foot := []gast.Node{}
+			for r := c.FirstChild(); r != nil; r = r.NextSibling() {
+				foot = append(foot, r)
+			}
+			sections = append(sections, foot)
+		}
+	}
+	if body != nil {
+		sections = append(sections, body)
+	}
+	return sections
+}
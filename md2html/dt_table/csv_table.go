@@ -0,0 +1,505 @@
+package dt_table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+	"github.com/1f408/cats_eeds/md2html/dt_table/ast"
+)
+
+var ErrNoTableLoader = errors.New("table loader is not configured")
+var ErrEmptyTable = errors.New("empty table source")
+
+// ColumnType is a value type of table column, used for client side sorting.
+type ColumnType int
+
+const (
+	ColumnAuto ColumnType = iota
+	ColumnText
+	ColumnNumber
+	ColumnDate
+)
+
+func (t ColumnType) String() string {
+	switch t {
+	case ColumnText:
+		return "text"
+	case ColumnNumber:
+		return "number"
+	case ColumnDate:
+		return "date"
+	}
+	return "auto"
+}
+
+func ParseColumnType(s string) (ColumnType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "auto":
+		return ColumnAuto, nil
+	case "text", "string":
+		return ColumnText, nil
+	case "number", "num":
+		return ColumnNumber, nil
+	case "date":
+		return ColumnDate, nil
+	}
+	return ColumnAuto, fmt.Errorf("unknown column type: %q", s)
+}
+
+// TableLoaderFunc reads a table source referenced by [!TABLE](link).
+type TableLoaderFunc func(link string) ([]byte, error)
+
+type CsvTableConfig struct {
+	Loader TableLoaderFunc
+	Report diag.ReportFunc
+}
+
+type CsvTableOption interface {
+	SetCsvTableOption(*CsvTableConfig)
+}
+
+type withTableLoader struct {
+	value TableLoaderFunc
+}
+
+func (o *withTableLoader) SetCsvTableOption(c *CsvTableConfig) {
+	c.Loader = o.value
+}
+
+func WithTableLoader(f TableLoaderFunc) CsvTableOption {
+	return &withTableLoader{value: f}
+}
+
+type withTableReport struct {
+	value diag.ReportFunc
+}
+
+func (o *withTableReport) SetCsvTableOption(c *CsvTableConfig) {
+	c.Report = o.value
+}
+
+func WithTableReport(f diag.ReportFunc) CsvTableOption {
+	return &withTableReport{value: f}
+}
+
+type tableHints struct {
+	Header  bool
+	Caption string
+	Types   []ColumnType
+	Attrs   parser.Attributes
+}
+
+func attrText(v any) string {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func parseTableHints(attr_src []byte) (*tableHints, error) {
+	hints := &tableHints{Header: true}
+	if len(bytes.TrimSpace(attr_src)) == 0 {
+		return hints, nil
+	}
+
+	attrs, ok := parser.ParseAttributes(text.NewReader(attr_src))
+	if !ok {
+		return hints, fmt.Errorf("invalid table attributes: %s", attr_src)
+	}
+
+	for _, a := range attrs {
+		switch string(a.Name) {
+		case "header":
+			h, err := strconv.ParseBool(attrText(a.Value))
+			if err != nil {
+				return hints, fmt.Errorf("invalid header attribute: %q", attrText(a.Value))
+			}
+			hints.Header = h
+		case "caption":
+			hints.Caption = attrText(a.Value)
+		case "types":
+			for _, s := range strings.Split(attrText(a.Value), ",") {
+				t, err := ParseColumnType(s)
+				if err != nil {
+					return hints, err
+				}
+				hints.Types = append(hints.Types, t)
+			}
+		default:
+			hints.Attrs = append(hints.Attrs, a)
+		}
+	}
+
+	return hints, nil
+}
+
+var numberRegexp = regexp.MustCompile(`^[-+]?([0-9]{1,3}(,[0-9]{3})+|[0-9]+)?(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+func parseNumber(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !numberRegexp.MatchString(s) {
+		return "", false
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", false
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), true
+}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+}
+
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+}
+
+func parseDate(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.Format("2006-01-02"), true
+		}
+	}
+	for _, l := range dateTimeLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.Format(time.RFC3339), true
+		}
+	}
+	return "", false
+}
+
+func cellValue(typ ColumnType, s string) (string, bool) {
+	switch typ {
+	case ColumnNumber:
+		return parseNumber(s)
+	case ColumnDate:
+		return parseDate(s)
+	}
+	return "", false
+}
+
+func guessColumnType(rows [][]string, col int) ColumnType {
+	is_num := true
+	is_date := true
+	found := false
+	for _, row := range rows {
+		if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+			continue
+		}
+		found = true
+		if _, ok := parseNumber(row[col]); !ok {
+			is_num = false
+		}
+		if _, ok := parseDate(row[col]); !ok {
+			is_date = false
+		}
+	}
+
+	switch {
+	case !found:
+		return ColumnText
+	case is_num:
+		return ColumnNumber
+	case is_date:
+		return ColumnDate
+	}
+	return ColumnText
+}
+
+func parseCsv(src []byte, comma rune) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(src))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	if comma == '\t' {
+		r.LazyQuotes = true
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrEmptyTable
+	}
+	return records, nil
+}
+
+func newCsvRow(record []string, types []ColumnType, is_header bool) *ast.TableRow {
+	aligns := make([]ast.Alignment, len(types))
+	for i := range aligns {
+		aligns[i] = ast.AlignNone
+	}
+
+	row := ast.NewTableRow(aligns)
+	for i, typ := range types {
+		cell := ast.NewTableCell()
+		cell.SetAttributeString("data-type", typ.String())
+
+		v := ""
+		if i < len(record) {
+			v = record[i]
+		}
+		if !is_header {
+			if norm, ok := cellValue(typ, v); ok {
+				cell.SetAttributeString("data-value", norm)
+			}
+		}
+		if v != "" {
+			str := gast.NewString([]byte(v))
+			str.SetRaw(true)
+			cell.AppendChild(cell, str)
+		}
+		row.AppendChild(row, cell)
+	}
+	return row
+}
+
+func newCsvTableNode(records [][]string, hints *tableHints, pc parser.Context) *ast.Table {
+	ncol := 0
+	for _, r := range records {
+		ncol = max(ncol, len(r))
+	}
+
+	body := records
+	if hints.Header {
+		body = records[1:]
+	}
+
+	types := make([]ColumnType, ncol)
+	for i := range types {
+		if i < len(hints.Types) && hints.Types[i] != ColumnAuto {
+			types[i] = hints.Types[i]
+			continue
+		}
+		types[i] = guessColumnType(body, i)
+	}
+
+	table := ast.NewTable()
+	for range types {
+		table.Alignments = append(table.Alignments, ast.AlignNone)
+	}
+
+	class := []byte("markdown-datatable")
+	for _, a := range hints.Attrs {
+		if string(a.Name) == "class" {
+			class = append(class, ' ')
+			class = append(class, attrText(a.Value)...)
+			continue
+		}
+		if string(a.Name) == "id" {
+			if id, ok := a.Value.([]byte); ok {
+				pc.IDs().Put(id)
+			}
+		}
+		table.SetAttribute(a.Name, a.Value)
+	}
+	table.SetAttributeString("class", class)
+
+	if hints.Header {
+		table.AppendChild(table, ast.NewTableHeader(newCsvRow(records[0], types, true)))
+	}
+	for _, r := range body {
+		table.AppendChild(table, newCsvRow(r, types, false))
+	}
+
+	if hints.Caption != "" {
+		cap := ast.NewTableCaption()
+		str := gast.NewString([]byte(hints.Caption))
+		str.SetRaw(true)
+		cap.AppendChild(cap, str)
+		setTableCaption(table, cap, []byte(hints.Caption), nil, pc)
+	}
+
+	return table
+}
+
+var tableRefRegexp = regexp.MustCompile(`^\[!TABLE\]\(\s*([^\s()]+)\s*\)\s*(\{.*\})?\s*$`)
+
+type csvTableTransformer struct {
+	CsvTableConfig
+}
+
+// NewCsvTableTransformer returns a parser.ASTTransformer that replaces
+// csv/tsv fenced code blocks and [!TABLE](file) references with tables.
+func NewCsvTableTransformer(opts ...CsvTableOption) parser.ASTTransformer {
+	t := &csvTableTransformer{}
+	for _, opt := range opts {
+		opt.SetCsvTableOption(&t.CsvTableConfig)
+	}
+	return t
+}
+
+func (t *csvTableTransformer) report(src []byte, n gast.Node, err error) {
+	if t.Report == nil {
+		return
+	}
+
+	d := &diag.Diagnostic{Severity: diag.Warning, Message: err.Error()}
+	switch n := n.(type) {
+	case *gast.FencedCodeBlock:
+		if n.Lines().Len() > 0 {
+			d.Line, _ = diag.Position(src, n.Lines().At(0).Start)
+			d.Line--
+		} else if n.Info != nil {
+			d.Line, _ = diag.Position(src, n.Info.Segment.Start)
+		}
+		d.Column = 1
+	default:
+		d.Line, d.Column = diag.Position(src, n.Lines().At(0).Start)
+	}
+	t.Report(d)
+}
+
+func (t *csvTableTransformer) fencedTable(fc *gast.FencedCodeBlock, src []byte, pc parser.Context) (*ast.Table, error) {
+	comma := ','
+	if bytes.Equal(fc.Language(src), []byte("tsv")) {
+		comma = '\t'
+	}
+
+	var attr_src []byte
+	if fc.Info != nil {
+		info := fc.Info.Segment.Value(src)
+		if i := bytes.IndexByte(info, '{'); i >= 0 {
+			attr_src = info[i:]
+		}
+	}
+	hints, err := parseTableHints(attr_src)
+	if err != nil {
+		return nil, err
+	}
+
+	var code bytes.Buffer
+	lines := fc.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		code.Write(seg.Value(src))
+	}
+
+	records, err := parseCsv(code.Bytes(), comma)
+	if err != nil {
+		return nil, err
+	}
+	return newCsvTableNode(records, hints, pc), nil
+}
+
+func (t *csvTableTransformer) refTable(m [][]byte, pc parser.Context) (*ast.Table, error) {
+	hints, err := parseTableHints(m[2])
+	if err != nil {
+		return nil, err
+	}
+	if t.Loader == nil {
+		return nil, ErrNoTableLoader
+	}
+
+	link := string(m[1])
+	bin, err := t.Loader(link)
+	if err != nil {
+		return nil, fmt.Errorf("table load error: %s: %w", link, err)
+	}
+
+	comma := ','
+	if strings.HasSuffix(strings.ToLower(link), ".tsv") {
+		comma = '\t'
+	}
+	records, err := parseCsv(bin, comma)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", link, err)
+	}
+	return newCsvTableNode(records, hints, pc), nil
+}
+
+func (t *csvTableTransformer) Transform(doc *gast.Document, reader text.Reader, pc parser.Context) {
+	src := reader.Source()
+
+	nodes := []gast.Node{}
+	gast.Walk(doc, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering {
+			return gast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *gast.FencedCodeBlock:
+			lang := string(n.Language(src))
+			if lang == "csv" || lang == "tsv" {
+				nodes = append(nodes, n)
+			}
+		case *gast.Paragraph:
+			if n.Lines().Len() == 1 {
+				nodes = append(nodes, n)
+			}
+			return gast.WalkSkipChildren, nil
+		}
+		return gast.WalkContinue, nil
+	})
+
+	for _, n := range nodes {
+		var table *ast.Table
+		var err error
+
+		switch n := n.(type) {
+		case *gast.FencedCodeBlock:
+			table, err = t.fencedTable(n, src, pc)
+		case *gast.Paragraph:
+			seg := n.Lines().At(0)
+			m := tableRefRegexp.FindSubmatch(bytes.TrimSpace(seg.Value(src)))
+			if m == nil {
+				continue
+			}
+			table, err = t.refTable(m, pc)
+		}
+		if err != nil {
+			t.report(src, n, err)
+			continue
+		}
+
+		table.SetPos(n.Pos())
+		n.Parent().ReplaceChild(n.Parent(), n, table)
+	}
+}
+
+type csvTable struct {
+	options []CsvTableOption
+}
+
+// NewCsvTable returns an extension that builds data tables from CSV/TSV.
+// It must be used with Table, which renders the table nodes.
+func NewCsvTable(opts ...CsvTableOption) goldmark.Extender {
+	return &csvTable{
+		options: opts,
+	}
+}
+
+func (e *csvTable) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(NewCsvTableTransformer(e.options...), 100),
+		),
+	)
+}
@@ -0,0 +1,206 @@
+package dt_table
+
+import (
+	"bytes"
+
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/dt_table/ast"
+)
+
+// trimRowContinuation strips a trailing row-continuation marker ('\' after
+// the last '|'), which joins the next line to the row.
+func trimRowContinuation(seg text.Segment, source []byte) (text.Segment, bool) {
+	line := seg.Value(source)
+	end := len(util.TrimRightSpace(line))
+	if end < 2 || line[end-1] != '\\' {
+		return seg, false
+	}
+
+	body := util.TrimRightSpace(line[:end-1])
+	if len(body) == 0 || body[len(body)-1] != '|' {
+		return seg, false
+	}
+	if len(body) >= 2 && body[len(body)-2] == '\\' {
+		return seg, false
+	}
+
+	return seg.WithStop(seg.Start + len(body)), true
+}
+
+func appendRowLines(dst *ast.TableRow, src *ast.TableRow, pc parser.Context) {
+	var escaped []*escapedPipeCell
+	if lst := pc.Get(escapedPipeCellListKey); lst != nil {
+		escaped = lst.([]*escapedPipeCell)
+	}
+
+	dc := dst.FirstChild()
+	for sc := src.FirstChild(); sc != nil && dc != nil; sc = sc.NextSibling() {
+		lines := sc.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			dc.Lines().Append(lines.At(i))
+		}
+		for _, e := range escaped {
+			if e.Cell == sc {
+				e.Cell = dc.(*ast.TableCell)
+			}
+		}
+		dc = dc.NextSibling()
+	}
+}
+
+// splitMultilineCells moves each line of a continued cell into its own
+// paragraph, grouped by blank lines. The groups are flattened after
+// inline parsing by joinMultilineCell.
+func splitMultilineCells(table *ast.Table) {
+	walkTableCells(table, func(c gast.Node) {
+		lines := c.Lines()
+		if lines.Len() < 2 {
+			return
+		}
+
+		var group *gast.Paragraph
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			if seg.IsEmpty() {
+				group = nil
+				continue
+			}
+			if group == nil {
+				group = gast.NewParagraph()
+				c.AppendChild(c, group)
+			}
+			line := gast.NewParagraph()
+			line.Lines().Append(seg)
+			group.AppendChild(group, line)
+		}
+		c.SetLines(text.NewSegments())
+	})
+}
+
+func walkTableCells(table *ast.Table, fn func(c gast.Node)) {
+	for _, rows := range tableSections(table) {
+		for _, r := range rows {
+			for c := r.FirstChild(); c != nil; c = c.NextSibling() {
+				fn(c)
+			}
+		}
+	}
+}
+
+func isLineGroup(n gast.Node) bool {
+	p, ok := n.(*gast.Paragraph)
+	if !ok {
+		return false
+	}
+	_, ok = p.FirstChild().(*gast.Paragraph)
+	return ok
+}
+
+func joinMultilineCell(cell gast.Node) {
+	groups := 0
+	for g := cell.FirstChild(); g != nil; g = g.NextSibling() {
+		if !isLineGroup(g) {
+			return
+		}
+		groups++
+	}
+
+	for g := cell.FirstChild(); g != nil; g = g.NextSibling() {
+		for line := g.FirstChild(); line != nil; {
+			next := line.NextSibling()
+			for in := line.FirstChild(); in != nil; {
+				in_next := in.NextSibling()
+				g.InsertBefore(g, line, in)
+				in = in_next
+			}
+			if next != nil {
+				br := gast.NewTextSegment(text.NewSegment(0, 0))
+				br.SetSoftLineBreak(true)
+				g.InsertBefore(g, line, br)
+			}
+			g.RemoveChild(g, line)
+			line = next
+		}
+	}
+
+	if groups != 1 {
+		return
+	}
+	g := cell.FirstChild()
+	for in := g.FirstChild(); in != nil; {
+		next := in.NextSibling()
+		cell.AppendChild(cell, in)
+		in = next
+	}
+	cell.RemoveChild(cell, g)
+}
+
+func spanMarker(cell *ast.TableCell, source []byte) byte {
+	if cell.Lines().Len() != 1 {
+		return 0
+	}
+	seg := cell.Lines().At(0)
+	v := bytes.TrimSpace(seg.Value(source))
+	if len(v) != 1 || (v[0] != '<' && v[0] != '^') {
+		return 0
+	}
+	return v[0]
+}
+
+type spanPos struct {
+	row int
+	col int
+}
+
+// mergeSpanCells merges '<' cells into the cell on the left and '^' cells
+// into the cell above. Cells never span across table sections.
+func mergeSpanCells(table *ast.Table, source []byte) {
+	for _, rows := range tableSections(table) {
+		mergeSectionSpans(rows, source)
+	}
+}
+
+func mergeSectionSpans(rows []gast.Node, source []byte) {
+	owners := [][]*ast.TableCell{}
+	origin := map[*ast.TableCell]spanPos{}
+
+	for r, row := range rows {
+		owners = append(owners, []*ast.TableCell{})
+
+		c := 0
+		for n := row.FirstChild(); n != nil; c++ {
+			next := n.NextSibling()
+			cell := n.(*ast.TableCell)
+
+			var owner *ast.TableCell
+			switch spanMarker(cell, source) {
+			case '<':
+				if c > 0 {
+					owner = owners[r][c-1]
+				}
+			case '^':
+				if r > 0 && c < len(owners[r-1]) {
+					owner = owners[r-1][c]
+				}
+			}
+
+			if owner == nil {
+				origin[cell] = spanPos{row: r, col: c}
+				owners[r] = append(owners[r], cell)
+				n = next
+				continue
+			}
+
+			pos := origin[owner]
+			owner.ColSpan = max(owner.ColSpan, c-pos.col+1)
+			owner.RowSpan = max(owner.RowSpan, r-pos.row+1)
+			owners[r] = append(owners[r], owner)
+			row.RemoveChild(row, cell)
+			n = next
+		}
+	}
+}
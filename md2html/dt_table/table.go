@@ -169,21 +169,69 @@ func (b *tableParagraphTransformer) Transform(node *gast.Paragraph, reader text.
 		if header == nil || len(alignments) != header.ChildCount() {
 			return
 		}
+		source := reader.Source()
 		table := ast.NewTable()
 		table.Alignments = alignments
 		table.SetPos(ppos)
 		table.AppendChild(table, ast.NewTableHeader(header))
+
+		head := i - 1
+		var cap *ast.TableCaption
+		var cap_seg text.Segment
+		var cap_attrs parser.Attributes
+		if i >= 2 {
+			if seg, attrs, ok := parseCaptionLine(lines.At(i-2), source); ok {
+				head = i - 2
+				cap_seg, cap_attrs = seg, attrs
+				cap = ast.NewTableCaption()
+			}
+		}
+
+		var rows gast.Node = table
+		var cont *ast.TableRow
 		for j := i + 1; j < lines.Len(); j++ {
-			table.AppendChild(table, b.parseRow(lines.At(j), alignments, false, reader, pc))
+			if cap == nil && j == lines.Len()-1 {
+				if seg, attrs, ok := parseCaptionLine(lines.At(j), source); ok {
+					cap_seg, cap_attrs = seg, attrs
+					cap = ast.NewTableCaption()
+					break
+				}
+			}
+			line := lines.At(j)
+			if rows == gast.Node(table) && cont == nil && isFooterDelim(line.Value(source)) {
+				rows = ast.NewTableFooter()
+				table.AppendChild(table, rows)
+				continue
+			}
+
+			seg, is_cont := trimRowContinuation(lines.At(j), source)
+			row := b.parseRow(seg, alignments, false, reader, pc)
+			if cont != nil {
+				appendRowLines(cont, row, pc)
+			} else {
+				rows.AppendChild(rows, row)
+				if is_cont {
+					cont = row
+				}
+			}
+			if !is_cont {
+				cont = nil
+			}
+		}
+		splitMultilineCells(table)
+		mergeSpanCells(table, source)
+		if cap != nil {
+			cap.Lines().Append(cap_seg)
+			setTableCaption(table, cap, cap_seg.Value(source), cap_attrs, pc)
 		}
-		node.Lines().SetSliced(0, i-1)
+		node.Lines().SetSliced(0, head)
 		node.Parent().InsertAfter(node.Parent(), node, table)
 		if node.Lines().Len() == 0 {
 			node.Parent().RemoveChild(node.Parent(), node)
 		} else {
-			last := node.Lines().At(i - 2)
+			last := node.Lines().At(head - 1)
 			last.Stop = last.Stop - 1 // trim last newline(\n)
-			node.Lines().Set(i-2, last)
+			node.Lines().Set(head-1, last)
 		}
 	}
 }
@@ -357,6 +405,8 @@ func (a *dataTableASTTransformer) transformTable(n gast.Node) (gast.WalkStatus,
 		return gast.WalkContinue, nil
 	}
 
+	walkTableCells(n.(*ast.Table), joinMultilineCell)
+
 	has_row := false
 	all_em := true
 	for c := n.FirstChild() ; c != nil ; c = c.NextSibling() {
@@ -443,6 +493,8 @@ func (r *TableHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegistere
 	reg.Register(ast.KindTableHeader, r.renderTableHeader)
 	reg.Register(ast.KindTableRow, r.renderTableRow)
 	reg.Register(ast.KindTableCell, r.renderTableCell)
+	reg.Register(ast.KindTableCaption, r.renderTableCaption)
+	reg.Register(ast.KindTableFooter, r.renderTableFooter)
 }
 
 // TableAttributeFilter defines attribute names which table elements can have.
@@ -493,9 +545,6 @@ func (r *TableHTMLRenderer) renderTableHeader(
 	} else {
 		_, _ = w.WriteString("</tr>\n")
 		_, _ = w.WriteString("</thead>\n")
-		if n.NextSibling() != nil {
-			_, _ = w.WriteString("<tbody>\n")
-		}
 	}
 	return gast.WalkContinue, nil
 }
@@ -511,7 +560,11 @@ var TableRowAttributeFilter = html.GlobalAttributeFilter.ExtendString(`align,bgc
 
 func (r *TableHTMLRenderer) renderTableRow(
 	w util.BufWriter, source []byte, n gast.Node, entering bool) (gast.WalkStatus, error) {
+	in_body := n.Parent().Kind() == ast.KindTable
 	if entering {
+		if in_body && !isTableRow(n.PreviousSibling()) {
+			_, _ = w.WriteString("<tbody>\n")
+		}
 		_, _ = w.WriteString("<tr")
 		if n.Attributes() != nil {
 			html.RenderAttributes(w, n, TableRowAttributeFilter)
@@ -519,13 +572,51 @@ func (r *TableHTMLRenderer) renderTableRow(
 		_, _ = w.WriteString(">\n")
 	} else {
 		_, _ = w.WriteString("</tr>\n")
-		if n.Parent().LastChild() == n {
+		if in_body && !isTableRow(n.NextSibling()) {
 			_, _ = w.WriteString("</tbody>\n")
 		}
 	}
 	return gast.WalkContinue, nil
 }
 
+func isTableRow(n gast.Node) bool {
+	return n != nil && n.Kind() == ast.KindTableRow
+}
+
+// TableFooterAttributeFilter defines attribute names which <tfoot> elements can have.
+var TableFooterAttributeFilter = TableHeaderAttributeFilter
+
+func (r *TableHTMLRenderer) renderTableFooter(
+	w util.BufWriter, source []byte, n gast.Node, entering bool) (gast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString("<tfoot")
+		if n.Attributes() != nil {
+			html.RenderAttributes(w, n, TableFooterAttributeFilter)
+		}
+		_, _ = w.WriteString(">\n")
+	} else {
+		_, _ = w.WriteString("</tfoot>\n")
+	}
+	return gast.WalkContinue, nil
+}
+
+// TableCaptionAttributeFilter defines attribute names which <caption> elements can have.
+var TableCaptionAttributeFilter = html.GlobalAttributeFilter
+
+func (r *TableHTMLRenderer) renderTableCaption(
+	w util.BufWriter, source []byte, n gast.Node, entering bool) (gast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString("<caption")
+		if n.Attributes() != nil {
+			html.RenderAttributes(w, n, TableCaptionAttributeFilter)
+		}
+		_ = w.WriteByte('>')
+	} else {
+		_, _ = w.WriteString("</caption>\n")
+	}
+	return gast.WalkContinue, nil
+}
+
 // TableThCellAttributeFilter defines attribute names which table <th> cells can have.
 //
 //   - abbr:  [OK] Contains a short abbreviated description of the cell's content [NOT OK in <td>]
@@ -572,6 +663,12 @@ func (r *TableHTMLRenderer) renderTableCell(
 	}
 	if entering {
 		_, _ = fmt.Fprintf(w, "<%s", tag)
+		if _, ok := n.AttributeString("colspan"); !ok && n.ColSpan > 1 {
+			_, _ = fmt.Fprintf(w, ` colspan="%d"`, n.ColSpan)
+		}
+		if _, ok := n.AttributeString("rowspan"); !ok && n.RowSpan > 1 {
+			_, _ = fmt.Fprintf(w, ` rowspan="%d"`, n.RowSpan)
+		}
 		if n.Alignment != ast.AlignNone {
 			amethod := r.TableConfig.TableCellAlignMethod
 			if amethod == TableCellAlignDefault {
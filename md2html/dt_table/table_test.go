@@ -1,6 +1,8 @@
 package dt_table_test
 
 import (
+	"io/fs"
+	"strings"
 	"testing"
 
 	"github.com/yuin/goldmark"
@@ -11,6 +13,7 @@ import (
 	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
 
+	"github.com/1f408/cats_eeds/md2html/diag"
 	"github.com/1f408/cats_eeds/md2html/dt_table"
 	east "github.com/1f408/cats_eeds/md2html/dt_table/ast"
 )
@@ -394,3 +397,38 @@ func TestTableFuzzedPanics(t *testing.T) {
 		t,
 	)
 }
+
+func TestCsvTable(t *testing.T) {
+	files := map[string]string{
+		"data.csv": "id,score\nx,-3.5\n",
+	}
+	diags := []*diag.Diagnostic{}
+	markdown := goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+			html.WithXHTML(),
+		),
+		goldmark.WithExtensions(
+			dt_table.Table,
+			dt_table.NewCsvTable(
+				dt_table.WithTableLoader(func(link string) ([]byte, error) {
+					if s, ok := files[link]; ok {
+						return []byte(s), nil
+					}
+					return nil, fs.ErrNotExist
+				}),
+				dt_table.WithTableReport(func(d *diag.Diagnostic) {
+					diags = append(diags, d)
+				}),
+			),
+		),
+	)
+	testutil.DoTestCaseFile(markdown, "_test/csv_table.txt", t, testutil.ParseCliCaseArg()...)
+
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics, want 2: %v", len(diags), diags)
+	}
+	if diags[0].Line != 1 || diags[1].Line != 5 || !strings.Contains(diags[1].Message, "missing.csv") {
+		t.Errorf("got diagnostics %v", diags)
+	}
+}
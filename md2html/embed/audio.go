@@ -11,6 +11,7 @@ import (
 
 type NodeAudio struct {
 	ast.Image
+	Media *LocalMedia
 }
 
 var KindAudio = ast.NewNodeKind("Audio")
@@ -39,6 +40,7 @@ func (r *HTMLRenderer) renderAudio(w util.BufWriter, source []byte, node ast.Nod
 			html.RenderAttributes(w, node, nil)
 		}
 		w.WriteByte('>')
+		renderLocalMedia(w, vnode.Media)
 	} else {
 		w.WriteString(`</audio>`)
 
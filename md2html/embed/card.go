@@ -0,0 +1,251 @@
+package embed
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"regexp"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+var ErrNoCardMeta = errors.New("no link card metadata")
+
+type CardMeta struct {
+	Title       string
+	Description string
+	Favicon     string
+}
+
+// A CardFetcher returns the metadata of a link card.
+// It is called at render time, so it should not block on the network.
+type CardFetcher interface {
+	FetchCard(url string) (*CardMeta, error)
+}
+
+// A CardCache is an offline CardFetcher keyed by URL.
+type CardCache map[string]*CardMeta
+
+func (cc CardCache) FetchCard(url string) (*CardMeta, error) {
+	if m, ok := cc[url]; ok && m != nil {
+		return m, nil
+	}
+	return nil, ErrNoCardMeta
+}
+
+type NodeCard struct {
+	ast.BaseBlock
+	Url   string
+	Label string
+	Meta  *CardMeta
+}
+
+var KindCard = ast.NewNodeKind("Card")
+
+func (n *NodeCard) Kind() ast.NodeKind {
+	return KindCard
+}
+
+func (n *NodeCard) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Url":   n.Url,
+		"Label": n.Label,
+	}, nil)
+}
+
+func NewCard(name string, url string, label string, meta *CardMeta) *NodeCard {
+	n := &NodeCard{
+		Url:   url,
+		Label: label,
+		Meta:  meta,
+	}
+
+	n.SetAttributeString("class", []byte("link-card link-card-"+name))
+	return n
+}
+
+var bareUrlRegexp = regexp.MustCompile(`^https?://\S+$`)
+
+// standaloneUrl returns the URL and link text of a paragraph that holds
+// a single link or bare URL.
+func standaloneUrl(p *ast.Paragraph, src []byte) (string, string, bool) {
+	switch c := p.FirstChild().(type) {
+	case *ast.AutoLink:
+		if c.NextSibling() != nil || c.AutoLinkType != ast.AutoLinkURL {
+			return "", "", false
+		}
+		return string(c.URL(src)), "", true
+	case *ast.Link:
+		if c.NextSibling() != nil {
+			return "", "", false
+		}
+		var label bytes.Buffer
+		for t := c.FirstChild(); t != nil; t = t.NextSibling() {
+			if tx, ok := t.(*ast.Text); ok {
+				label.Write(tx.Value(src))
+			}
+		}
+		return string(c.Destination), label.String(), true
+	}
+
+	var line bytes.Buffer
+	for c := p.FirstChild(); c != nil; c = c.NextSibling() {
+		tx, ok := c.(*ast.Text)
+		if !ok {
+			return "", "", false
+		}
+		line.Write(tx.Value(src))
+	}
+	v := bytes.TrimSpace(line.Bytes())
+	if !bareUrlRegexp.Match(v) {
+		return "", "", false
+	}
+	return string(v), "", true
+}
+
+func (at *embedTransformer) reportCard(p *ast.Paragraph, src []byte, msg string) {
+	if at.Report == nil {
+		return
+	}
+
+	line, col := 0, 0
+	if p.Lines().Len() > 0 {
+		line, col = diag.Position(src, p.Lines().At(0).Start)
+	}
+	at.Report(&diag.Diagnostic{
+		Severity: diag.Warning,
+		Line:     line,
+		Column:   col,
+		Message:  msg,
+	})
+}
+
+func (at *embedTransformer) transformCard(p *ast.Paragraph, src []byte) (ast.WalkStatus, error) {
+	if len(at.Host2Card) == 0 {
+		return ast.WalkContinue, nil
+	}
+
+	link, label, ok := standaloneUrl(p, src)
+	if !ok || html.IsDangerousURL([]byte(link)) {
+		return ast.WalkContinue, nil
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ast.WalkContinue, nil
+	}
+
+	for _, pat := range at.Host2Card[u.Host] {
+		if pat.Match(u) == nil {
+			continue
+		}
+
+		var meta *CardMeta
+		if at.CardFetcher != nil {
+			meta, err = at.CardFetcher.FetchCard(u.String())
+			if err != nil {
+				at.reportCard(p, src, err.Error()+": "+u.String())
+			}
+		}
+
+		cn := NewCard(pat.SiteId, u.String(), label, meta)
+		p.Parent().ReplaceChild(p.Parent(), p, cn)
+		return ast.WalkSkipChildren, nil
+	}
+
+	return ast.WalkContinue, nil
+}
+
+func (r *HTMLRenderer) renderCard(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*NodeCard)
+	title := n.Label
+	desc := ""
+	favicon := ""
+	if n.Meta != nil {
+		if n.Meta.Title != "" {
+			title = n.Meta.Title
+		}
+		desc = n.Meta.Description
+		favicon = n.Meta.Favicon
+	}
+	if title == "" {
+		title = n.Url
+	}
+
+	w.WriteString(`<div`)
+	html.RenderAttributes(w, node, nil)
+	w.WriteString(`><a class="link-card_link" href="`)
+	w.Write(util.EscapeHTML(util.URLEscape([]byte(n.Url), true)))
+	w.WriteString(`">`)
+	if favicon != "" && !html.IsDangerousURL([]byte(favicon)) {
+		w.WriteString(`<img class="link-card_favicon" src="`)
+		w.Write(util.EscapeHTML(util.URLEscape([]byte(favicon), true)))
+		w.WriteString(`" alt="" />`)
+	}
+	w.WriteString(`<span class="link-card_title">`)
+	w.Write(util.EscapeHTML([]byte(title)))
+	w.WriteString(`</span>`)
+	if desc != "" {
+		w.WriteString(`<span class="link-card_description">`)
+		w.Write(util.EscapeHTML([]byte(desc)))
+		w.WriteString(`</span>`)
+	}
+	w.WriteString(`<span class="link-card_url">`)
+	w.Write(util.EscapeHTML([]byte(n.Url)))
+	w.WriteString("</span></a></div>\n")
+
+	return ast.WalkSkipChildren, nil
+}
+
+type withEmbedCardUrl struct {
+	h2pat map[string][]*UrlPattern
+}
+
+func (o *withEmbedCardUrl) SetEmbedOption(c *EmbedConfig) {
+	c.Host2Card = o.h2pat
+}
+
+type CardOptions struct {
+	SiteId string
+	Host   string
+	Type   string
+	Path   string
+	Query  string
+	Regex  *regexp.Regexp
+}
+
+func WithEmbedCardUrl(ops []CardOptions) EmbedOption {
+	h2pat := map[string][]*UrlPattern{}
+
+	for _, o := range ops {
+		h2pat[o.Host] = append(h2pat[o.Host],
+			&UrlPattern{
+				SiteId: o.SiteId,
+				Type:   o.Type,
+				Path:   o.Path,
+				Query:  o.Query,
+				Regex:  o.Regex,
+			},
+		)
+	}
+	return &withEmbedCardUrl{h2pat: h2pat}
+}
+
+type withEmbedCardFetcher struct {
+	value CardFetcher
+}
+
+func (o *withEmbedCardFetcher) SetEmbedOption(c *EmbedConfig) {
+	c.CardFetcher = o.value
+}
+
+func WithEmbedCardFetcher(f CardFetcher) EmbedOption {
+	return &withEmbedCardFetcher{value: f}
+}
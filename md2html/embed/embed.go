@@ -4,6 +4,7 @@ import (
 	"net/url"
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/yuin/goldmark"
@@ -13,6 +14,8 @@ import (
 	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
 )
 
 type embedExtension struct {
@@ -44,12 +47,28 @@ type EmbedConfig struct {
 	Host2Audio  map[string][]*NoExtPattern
 	Host2Video  map[string][]*NoExtPattern
 	Host2Iframe map[string][]*UrlPattern
+	Host2Card   map[string][]*UrlPattern
+	CardFetcher CardFetcher
+	MediaList   MediaListFunc
+	Report      diag.ReportFunc
 }
 
 type EmbedOption interface {
 	SetEmbedOption(*EmbedConfig)
 }
 
+type withEmbedReport struct {
+	value diag.ReportFunc
+}
+
+func (o *withEmbedReport) SetEmbedOption(c *EmbedConfig) {
+	c.Report = o.value
+}
+
+func WithEmbedReport(f diag.ReportFunc) EmbedOption {
+	return &withEmbedReport{value: f}
+}
+
 type embedTransformer struct {
 	EmbedConfig
 }
@@ -62,6 +81,7 @@ func NewEmbedTransformer(opts ...EmbedOption) parser.ASTTransformer {
 			Host2Video:  map[string][]*NoExtPattern{},
 			Host2Audio:  map[string][]*NoExtPattern{},
 			Host2Iframe: map[string][]*UrlPattern{},
+			Host2Card:   map[string][]*UrlPattern{},
 		},
 	}
 
@@ -87,6 +107,12 @@ type UrlPattern struct {
 	Player         string
 	Allow          string
 	Referrerpolicy string
+	Sandbox        *string
+	Loading        string
+	Width          int
+	Height         int
+	Consent        bool
+	ConsentText    string
 }
 
 var rePlayerId = regexp.MustCompile(`\$.`)
@@ -111,6 +137,33 @@ func (up *UrlPattern) PlayerUrl(id []string) string {
 		})
 }
 
+// Match returns the ids captured from u, or nil if u does not match.
+func (up *UrlPattern) Match(u *url.URL) []string {
+	switch up.Type {
+	case "query":
+		if up.Query == "" {
+			return nil
+		}
+		if path.Clean(u.Path) != path.Clean(up.Path) {
+			return nil
+		}
+
+		return []string{u.Query().Get(up.Query)}
+	case "path":
+		dir, file := path.Split(u.Path)
+		if path.Clean(dir) != path.Clean(up.Path) {
+			return nil
+		}
+
+		return []string{file}
+	case "regex":
+		if up.Regex != nil {
+			return up.Regex.FindStringSubmatch(u.Path)
+		}
+	}
+	return nil
+}
+
 func (at *embedTransformer) isAudioExt(ext string) bool {
 	if ext == "" {
 		return false
@@ -129,7 +182,37 @@ func (at *embedTransformer) isVideoExt(ext string) bool {
 	return ok
 }
 
-func (at *embedTransformer) transformNode(n ast.Node) (ast.WalkStatus, error) {
+func (at *embedTransformer) reportUnmatched(img *ast.Image, src []byte) {
+	if at.Report == nil {
+		return
+	}
+
+	line, col := diag.Position(src, img.Pos())
+	at.Report(&diag.Diagnostic{
+		Severity: diag.Warning,
+		Line:     line,
+		Column:   col,
+		Message:  "embed URL matches no rule: " + string(img.Destination),
+	})
+}
+
+func (at *embedTransformer) isEmbedHost(host string) bool {
+	if _, ok := at.Host2Video[host]; ok {
+		return true
+	}
+	if _, ok := at.Host2Audio[host]; ok {
+		return true
+	}
+	if _, ok := at.Host2Iframe[host]; ok {
+		return true
+	}
+	return false
+}
+
+func (at *embedTransformer) transformNode(n ast.Node, src []byte) (ast.WalkStatus, error) {
+	if p, ok := n.(*ast.Paragraph); ok {
+		return at.transformCard(p, src)
+	}
 	if n.Kind() != ast.KindImage {
 		return ast.WalkContinue, nil
 	}
@@ -184,31 +267,8 @@ func (at *embedTransformer) transformNode(n ast.Node) (ast.WalkStatus, error) {
 	}
 
 	if pats, ok := at.Host2Iframe[u.Host]; ok {
-		var v []string
 		for _, pat := range pats {
-			v = nil
-			if pat.Type == "query" {
-				if pat.Query == "" {
-					continue
-				}
-				if path.Clean(u.Path) != path.Clean(pat.Path) {
-					continue
-				}
-
-				v = []string{u.Query().Get(pat.Query)}
-			} else if pat.Type == "path" {
-				dir, file := path.Split(u.Path)
-				if path.Clean(dir) != path.Clean(pat.Path) {
-					continue
-				}
-
-				v = []string{file}
-			} else if pat.Type == "regex" {
-				if pat.Regex != nil {
-					v = pat.Regex.FindStringSubmatch(u.Path)
-				}
-			}
-
+			v := pat.Match(u)
 			if v == nil {
 				continue
 			}
@@ -228,6 +288,25 @@ func (at *embedTransformer) transformNode(n ast.Node) (ast.WalkStatus, error) {
 			if pat.Referrerpolicy != "" {
 				vn.SetAttributeString("referrerpolicy", []byte(pat.Referrerpolicy))
 			}
+			if pat.Sandbox != nil {
+				vn.SetAttributeString("sandbox", []byte(*pat.Sandbox))
+			}
+			if pat.Loading != "" {
+				vn.SetAttributeString("loading", []byte(pat.Loading))
+			}
+			if pat.Width > 0 {
+				vn.SetAttributeString("width", []byte(strconv.Itoa(pat.Width)))
+			}
+			if pat.Height > 0 {
+				vn.SetAttributeString("height", []byte(strconv.Itoa(pat.Height)))
+			}
+			if pat.Consent {
+				vn.Consent = true
+				vn.ConsentText = pat.ConsentText
+				if vn.ConsentText == "" {
+					vn.ConsentText = "Load content from " + u.Host
+				}
+			}
 			n.Parent().ReplaceChild(n.Parent(), n, vn)
 			return ast.WalkContinue, nil
 		}
@@ -235,6 +314,8 @@ func (at *embedTransformer) transformNode(n ast.Node) (ast.WalkStatus, error) {
 
 	if at.isVideoExt(path.Ext(u.Path)) {
 		vn := NewVideo(img, u.String())
+		vn.Media = at.probeLocalMedia(vn, u, true)
+		setLocalSource(vn, vn.Media)
 		n.Parent().ReplaceChild(n.Parent(), n, vn)
 
 		return ast.WalkContinue, nil
@@ -242,16 +323,25 @@ func (at *embedTransformer) transformNode(n ast.Node) (ast.WalkStatus, error) {
 
 	if at.isAudioExt(path.Ext(u.Path)) {
 		an := NewAudio(img, u.String())
+		an.Media = at.probeLocalMedia(an, u, false)
+		setLocalSource(an, an.Media)
 		n.Parent().ReplaceChild(n.Parent(), n, an)
 
 		return ast.WalkContinue, nil
 	}
 
+	if at.isEmbedHost(u.Host) {
+		at.reportUnmatched(img, src)
+	}
+
 	return ast.WalkContinue, nil
 }
 
 func (at *embedTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
-	ASTTWalk(node, at.transformNode)
+	src := reader.Source()
+	ASTTWalk(node, func(n ast.Node) (ast.WalkStatus, error) {
+		return at.transformNode(n, src)
+	})
 }
 
 type HTMLRenderer struct{}
@@ -265,4 +355,5 @@ func (r *HTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
 	reg.Register(KindIframe, r.renderIframe)
 	reg.Register(KindAudio, r.renderAudio)
 	reg.Register(KindVideo, r.renderVideo)
+	reg.Register(KindCard, r.renderCard)
 }
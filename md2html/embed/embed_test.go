@@ -0,0 +1,131 @@
+package embed
+
+import (
+	"errors"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/testutil"
+
+	"github.com/1f408/cats_eeds/internal/ftype"
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+var errOutside = errors.New("outside document root")
+
+var testMediaDirs = map[string][]string{
+	"movie": {"clip.en.vtt", "clip.jpg", "clip.mp4", "clip.vtt", "clip.webp", "clip.x.y.vtt", "other.vtt"},
+	"music": {"song.mp3", "song.vtt"},
+	"plain": {"bare.mp4"},
+}
+
+func testMediaList(link string) ([]string, error) {
+	link = path.Clean(link)
+	if strings.HasPrefix(link, "../") {
+		return nil, errOutside
+	}
+
+	names, ok := testMediaDirs[path.Dir(link)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return names, nil
+}
+
+func TestMain(m *testing.M) {
+	ftype.SetExtMimeType("mp4", "video/mp4")
+	ftype.SetExtMimeType("mp3", "audio/mpeg")
+	os.Exit(m.Run())
+}
+
+func TestLocalMedia(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+		goldmark.WithExtensions(
+			NewEmbed(
+				WithEmbedVideoExt([]string{"mp4"}),
+				WithEmbedAudioExt([]string{"mp3"}),
+				WithEmbedMediaList(testMediaList),
+			),
+		),
+	)
+	testutil.DoTestCaseFile(markdown, "_test/local_media.txt", t, testutil.ParseCliCaseArg()...)
+}
+
+var testCardCache = CardCache{
+	"https://example.com/posts/1": {
+		Title:       "First <post>",
+		Description: "The first post",
+		Favicon:     "https://example.com/favicon.ico",
+	},
+	"https://example.com/posts/3": {
+		Title:   "Third post",
+		Favicon: "javascript:alert(1)",
+	},
+}
+
+func TestCard(t *testing.T) {
+	diags := []*diag.Diagnostic{}
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewEmbed(
+				WithEmbedCardUrl([]CardOptions{
+					{SiteId: "example", Host: "example.com", Type: "path", Path: "/posts"},
+				}),
+				WithEmbedCardFetcher(testCardCache),
+				WithEmbedReport(func(d *diag.Diagnostic) {
+					diags = append(diags, d)
+				}),
+			),
+		),
+	)
+	testutil.DoTestCaseFile(markdown, "_test/card.txt", t, testutil.ParseCliCaseArg()...)
+
+	want := []string{
+		":3:1: warning: no link card metadata: https://example.com/posts/2",
+	}
+	got := []string{}
+	for _, d := range diags {
+		got = append(got, d.String())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIframe(t *testing.T) {
+	sandbox := "allow-scripts"
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewEmbed(
+				WithEmbedIframeUrl([]IframeOptions{
+					{
+						SiteId: "tube", Host: "tube.example.com", Type: "query", Path: "/watch", Query: "v",
+						Player: "https://tube.example.com/embed/$0",
+					},
+					{
+						SiteId: "map", Host: "map.example.com", Type: "path", Path: "/place",
+						Player: "https://map.example.com/embed/$0", Allow: "geolocation",
+						Sandbox: &sandbox, Loading: "lazy", Width: 640, Height: 360,
+						Consent: true,
+					},
+					{
+						SiteId: "slide", Host: "slide.example.com", Type: "regex",
+						Regex:   regexp.MustCompile(`^/deck/([0-9]+)$`),
+						Player:  "https://slide.example.com/embed/$1",
+						Consent: true, ConsentText: "Load <slides>",
+					},
+				}),
+			),
+		),
+	)
+	testutil.DoTestCaseFile(markdown, "_test/iframe.txt", t, testutil.ParseCliCaseArg()...)
+}
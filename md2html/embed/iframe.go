@@ -10,6 +10,8 @@ import (
 
 type NodeIframe struct {
 	ast.Image
+	Consent     bool
+	ConsentText string
 }
 
 var KindIframe = ast.NewNodeKind("Iframe")
@@ -30,8 +32,41 @@ func NewIframe(img *ast.Image, name string, url string) *NodeIframe {
 	return n
 }
 
+// renderIframeConsent renders a click-to-load placeholder, which keeps the
+// iframe attributes as data-embed-* attributes.
+func (r *HTMLRenderer) renderIframeConsent(w util.BufWriter, vnode *NodeIframe, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		w.WriteString(`</span><button type="button" class="embed-consent_button">`)
+		w.Write(util.EscapeHTML([]byte(vnode.ConsentText)))
+		w.WriteString(`</button></span>`)
+		return ast.WalkContinue, nil
+	}
+
+	w.WriteString(`<span`)
+	for _, a := range vnode.Attributes() {
+		name := string(a.Name)
+		value, _ := a.Value.([]byte)
+		if name == "class" {
+			w.WriteString(` class="`)
+			w.Write(util.EscapeHTML(value))
+			w.WriteString(` embed-consent"`)
+			continue
+		}
+		w.WriteString(` data-embed-`)
+		w.WriteString(name)
+		w.WriteString(`="`)
+		w.Write(util.EscapeHTML(value))
+		w.WriteByte('"')
+	}
+	w.WriteString(`><span class="embed-consent_title">`)
+	return ast.WalkContinue, nil
+}
+
 func (r *HTMLRenderer) renderIframe(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
 	vnode := node.(*NodeIframe)
+	if vnode.Consent {
+		return r.renderIframeConsent(w, vnode, entering)
+	}
 	if entering {
 		w.WriteString(`<iframe`)
 		if vnode.Attributes() != nil {
@@ -62,6 +97,12 @@ type IframeOptions struct {
 	Player         string
 	Allow          string
 	Referrerpolicy string
+	Sandbox        *string
+	Loading        string
+	Width          int
+	Height         int
+	Consent        bool
+	ConsentText    string
 }
 
 func WithEmbedIframeUrl(ops []IframeOptions) EmbedOption {
@@ -84,6 +125,12 @@ func WithEmbedIframeUrl(ops []IframeOptions) EmbedOption {
 				Player:         o.Player,
 				Allow:          o.Allow,
 				Referrerpolicy: o.Referrerpolicy,
+				Sandbox:        o.Sandbox,
+				Loading:        o.Loading,
+				Width:          o.Width,
+				Height:         o.Height,
+				Consent:        o.Consent,
+				ConsentText:    o.ConsentText,
 			},
 		)
 		h2pat[o.Host] = pats
@@ -0,0 +1,157 @@
+package embed
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/internal/ftype"
+)
+
+// A MediaListFunc returns the names of the files in the directory of a
+// local media link. It fails if the link does not name a local file.
+type MediaListFunc func(link string) ([]string, error)
+
+type withEmbedMediaList struct {
+	value MediaListFunc
+}
+
+func (o *withEmbedMediaList) SetEmbedOption(c *EmbedConfig) {
+	c.MediaList = o.value
+}
+
+func WithEmbedMediaList(f MediaListFunc) EmbedOption {
+	return &withEmbedMediaList{value: f}
+}
+
+type MediaTrack struct {
+	Src  string
+	Lang string
+}
+
+type LocalMedia struct {
+	Src    string
+	Type   string
+	Tracks []*MediaTrack
+}
+
+var posterExts = []string{".jpg", ".webp"}
+
+func localLink(u *url.URL) bool {
+	return u.Scheme == "" && u.Host == "" &&
+		u.Path != "" && !strings.HasPrefix(u.Path, "/")
+}
+
+func siblingUrl(u *url.URL, name string) string {
+	sib := &url.URL{Path: path.Join(path.Dir(u.Path), name)}
+	return sib.String()
+}
+
+// trackLang returns the language of a subtitle file, "" for name.vtt and
+// "en" for name.en.vtt.
+func trackLang(name string, base string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, base)
+	if !ok {
+		return "", false
+	}
+	rest, ok = strings.CutSuffix(rest, ".vtt")
+	if !ok {
+		return "", false
+	}
+	if rest == "" {
+		return "", true
+	}
+
+	lang, ok := strings.CutPrefix(rest, ".")
+	if !ok || lang == "" || strings.Contains(lang, ".") {
+		return "", false
+	}
+	return lang, true
+}
+
+// probeLocalMedia looks up the MIME type, subtitle tracks (name.vtt,
+// name.<lang>.vtt) and poster image (name.jpg, name.webp) of a local file.
+func (at *embedTransformer) probeLocalMedia(n ast.Node, u *url.URL, video bool) *LocalMedia {
+	if at.MediaList == nil || !localLink(u) {
+		return nil
+	}
+	names, err := at.MediaList(u.Path)
+	if err != nil {
+		return nil
+	}
+
+	file := path.Base(u.Path)
+	ext := path.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+
+	m := &LocalMedia{Src: u.String()}
+	if kind, mime := ftype.GetFileKindByExt(strings.ToLower(ext)); strings.HasPrefix(kind, "video/") ||
+		strings.HasPrefix(kind, "audio/") {
+		m.Type = mime
+	}
+	if !video {
+		return m
+	}
+
+	has := map[string]bool{}
+	for _, name := range names {
+		has[name] = true
+
+		if lang, ok := trackLang(name, base); ok {
+			m.Tracks = append(m.Tracks, &MediaTrack{Src: siblingUrl(u, name), Lang: lang})
+		}
+	}
+	for _, pe := range posterExts {
+		if has[base+pe] {
+			n.SetAttributeString("poster", []byte(siblingUrl(u, base+pe)))
+			break
+		}
+	}
+
+	return m
+}
+
+// setLocalSource moves the src attribute into the <source> element.
+func setLocalSource(n ast.Node, m *LocalMedia) {
+	if m == nil || m.Type == "" {
+		return
+	}
+
+	attrs := n.Attributes()
+	n.RemoveAttributes()
+	for _, a := range attrs {
+		if string(a.Name) != "src" {
+			n.SetAttribute(a.Name, a.Value)
+		}
+	}
+}
+
+func renderLocalMedia(w util.BufWriter, m *LocalMedia) {
+	if m == nil {
+		return
+	}
+
+	if m.Type != "" {
+		w.WriteString(`<source src="`)
+		w.Write(util.EscapeHTML([]byte(m.Src)))
+		w.WriteString(`" type="`)
+		w.Write(util.EscapeHTML([]byte(m.Type)))
+		w.WriteString(`" />`)
+	}
+	for _, t := range m.Tracks {
+		w.WriteString(`<track kind="subtitles" src="`)
+		w.Write(util.EscapeHTML([]byte(t.Src)))
+		w.WriteByte('"')
+		if t.Lang != "" {
+			w.WriteString(` srclang="`)
+			w.Write(util.EscapeHTML([]byte(t.Lang)))
+			w.WriteString(`" label="`)
+			w.Write(util.EscapeHTML([]byte(t.Lang)))
+			w.WriteByte('"')
+		}
+		w.WriteString(` />`)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 type NodeVideo struct {
 	ast.Image
+	Media *LocalMedia
 }
 
 var KindVideo = ast.NewNodeKind("Video")
@@ -39,6 +40,7 @@ func (r *HTMLRenderer) renderVideo(w util.BufWriter, source []byte, node ast.Nod
 			html.RenderAttributes(w, node, nil)
 		}
 		w.WriteByte('>')
+		renderLocalMedia(w, vnode.Media)
 	} else {
 		w.WriteString(`</video>`)
 
@@ -18,6 +18,7 @@ type EmbedRules struct {
 	Video    []VideoOpt  `toml:",omitempty"`
 	Audio    []AudioOpt  `toml:",omitempty"`
 	Iframe   []IframeOpt `toml:",omitempty"`
+	Card     []CardOpt   `toml:",omitempty"`
 	init     bool        `toml:"-"`
 }
 
@@ -145,8 +146,14 @@ type IframeOpt struct {
 	Query          string         `toml:",omitempty"`
 	Regex          *regexp.Regexp `toml:",omitempty"`
 	Player         string
-	Allow          string `toml:",omitempty"`
-	Referrerpolicy string `toml:",omitempty"`
+	Allow          string  `toml:",omitempty"`
+	Referrerpolicy string  `toml:",omitempty"`
+	Sandbox        *string `toml:",omitempty"`
+	Loading        string  `toml:",omitempty"`
+	Width          int     `toml:",omitempty"`
+	Height         int     `toml:",omitempty"`
+	Consent        bool    `toml:",omitempty"`
+	ConsentText    string  `toml:",omitempty"`
 }
 
 func (ifo *IframeOpt) UnmarshalTOML(decode func(interface{}) error) error {
@@ -158,8 +165,14 @@ func (ifo *IframeOpt) UnmarshalTOML(decode func(interface{}) error) error {
 		Query          string `toml:",omitempty"`
 		Regex          string `toml:",omitempty"`
 		Player         string
-		Allow          string `toml:",omitempty"`
-		Referrerpolicy string `toml:",omitempty"`
+		Allow          string  `toml:",omitempty"`
+		Referrerpolicy string  `toml:",omitempty"`
+		Sandbox        *string `toml:",omitempty"`
+		Loading        string  `toml:",omitempty"`
+		Width          int     `toml:",omitempty"`
+		Height         int     `toml:",omitempty"`
+		Consent        bool    `toml:",omitempty"`
+		ConsentText    string  `toml:",omitempty"`
 	}
 
 	var err error
@@ -185,10 +198,24 @@ func (ifo *IframeOpt) UnmarshalTOML(decode func(interface{}) error) error {
 	ifo.Player = rifo.Player
 	ifo.Allow = rifo.Allow
 	ifo.Referrerpolicy = rifo.Referrerpolicy
+	ifo.Sandbox = rifo.Sandbox
+	ifo.Loading = rifo.Loading
+	ifo.Width = rifo.Width
+	ifo.Height = rifo.Height
+	ifo.Consent = rifo.Consent
+	ifo.ConsentText = rifo.ConsentText
 
 	if ifo.Host == "" {
 		return fmt.Errorf("Missing 'host' parameter: %s", ifo.SiteId)
 	}
+	switch ifo.Loading {
+	case "", "lazy", "eager":
+	default:
+		return fmt.Errorf("No suppoted 'loading' value: %s", ifo.Loading)
+	}
+	if ifo.Width < 0 || ifo.Height < 0 {
+		return fmt.Errorf("Bad 'width' or 'height' parameter: %s", ifo.SiteId)
+	}
 	switch ifo.Type {
 	case "path":
 		if ifo.Path == "" {
@@ -210,3 +237,68 @@ func (ifo *IframeOpt) UnmarshalTOML(decode func(interface{}) error) error {
 	}
 	return nil
 }
+
+type CardOpt struct {
+	SiteId string
+	Host   string
+	Type   string
+	Path   string         `toml:",omitempty"`
+	Query  string         `toml:",omitempty"`
+	Regex  *regexp.Regexp `toml:",omitempty"`
+}
+
+func (co *CardOpt) UnmarshalTOML(decode func(interface{}) error) error {
+	type rawCardOpt struct {
+		SiteId string
+		Host   string
+		Type   string
+		Path   string `toml:",omitempty"`
+		Query  string `toml:",omitempty"`
+		Regex  string `toml:",omitempty"`
+	}
+
+	var err error
+	rco := rawCardOpt{}
+	if err = decode(&rco); err != nil {
+		return err
+	}
+
+	var re *regexp.Regexp = nil
+	if rco.Regex != "" {
+		re, err = regexp.Compile(rco.Regex)
+		if err != nil {
+			return err
+		}
+	}
+
+	co.SiteId = rco.SiteId
+	co.Host = rco.Host
+	co.Type = rco.Type
+	co.Path = rco.Path
+	co.Query = rco.Query
+	co.Regex = re
+
+	if co.Host == "" {
+		return fmt.Errorf("Missing 'host' parameter: %s", co.SiteId)
+	}
+	switch co.Type {
+	case "path":
+		if co.Path == "" {
+			return fmt.Errorf("No found 'path' parameter: %s", co.SiteId)
+		}
+	case "query":
+		if co.Path == "" {
+			return fmt.Errorf("No found 'path' parameter: %s", co.SiteId)
+		}
+		if co.Query == "" {
+			return fmt.Errorf("No found 'query' parameter: %s", co.SiteId)
+		}
+	case "regex":
+		if co.Regex == nil {
+			return fmt.Errorf("No found 'regex' parameter: %s", co.SiteId)
+		}
+	default:
+		return fmt.Errorf("No suppoted 'type' value: %s", co.Type)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package md2html
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/naoina/toml"
+	"github.com/yuin/goldmark"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+	"github.com/1f408/cats_eeds/md2html/uniqid"
+)
+
+type ExtensionFactory func(mc *MdConfig, id_tbl uniqid.IdsTable, inc_cfg *IncludeConfig) goldmark.Extender
+
+var extRegistry = struct {
+	mtx       sync.RWMutex
+	factories map[string]ExtensionFactory
+}{factories: map[string]ExtensionFactory{}}
+
+func normExtName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "")
+}
+
+func extFlagIndex(name string) int {
+	typ := reflect.TypeOf(ExtFlags{})
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.IsExported() && f.Type.Kind() == reflect.Bool &&
+			normExtName(f.Name) == normExtName(name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// RegisterExtension makes a goldmark extender available to markdown.conf by name.
+// It panics if name is empty, collides with a built-in flag or is already registered.
+func RegisterExtension(name string, factory ExtensionFactory) {
+	if name == "" || factory == nil {
+		panic("md2html: RegisterExtension: empty name or nil factory")
+	}
+	if extFlagIndex(name) >= 0 {
+		panic("md2html: RegisterExtension: built-in extension name: " + name)
+	}
+
+	extRegistry.mtx.Lock()
+	defer extRegistry.mtx.Unlock()
+
+	if _, dup := extRegistry.factories[name]; dup {
+		panic("md2html: RegisterExtension: duplicate name: " + name)
+	}
+	extRegistry.factories[name] = factory
+}
+
+func RegisteredExtensions() []string {
+	extRegistry.mtx.RLock()
+	defer extRegistry.mtx.RUnlock()
+
+	return sortedNames(extRegistry.factories)
+}
+
+func lookupExtension(name string) (ExtensionFactory, bool) {
+	extRegistry.mtx.RLock()
+	defer extRegistry.mtx.RUnlock()
+
+	f, ok := extRegistry.factories[name]
+	return f, ok
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type ExtensionOptions struct {
+	raw []byte
+}
+
+func (_ *ExtensionOptions) MakeNew() *ExtensionOptions {
+	return &ExtensionOptions{}
+}
+
+func (eo *ExtensionOptions) UnmarshalBinary(bin []byte) error {
+	eo.raw = append([]byte{}, bin...)
+	return nil
+}
+
+func (eo *ExtensionOptions) IsZero() bool {
+	return eo == nil || len(eo.raw) == 0
+}
+
+// Decode decodes the extension's options file into v.
+func (eo *ExtensionOptions) Decode(v any) error {
+	if eo.IsZero() {
+		return nil
+	}
+	return toml.Unmarshal(eo.raw, v)
+}
+
+func (mc *MdConfig) ExtensionOptions(name string) *ExtensionOptions {
+	if im, ok := mc.ExtOptions[name]; ok && im != nil && im.Value != nil {
+		return im.Value
+	}
+	return &ExtensionOptions{}
+}
+
+func (mc *MdConfig) EnabledExtensions() []string {
+	names := []string{}
+	seen := map[string]struct{}{}
+	add := func(name string) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+
+	for _, name := range mc.Extensions {
+		if enable, ok := mc.Extension.Named[name]; ok && !enable {
+			continue
+		}
+		add(name)
+	}
+	for _, name := range sortedNames(mc.Extension.Named) {
+		if mc.Extension.Named[name] {
+			add(name)
+		}
+	}
+
+	return names
+}
+
+func registeredParserExts(mc *MdConfig, id_tbl uniqid.IdsTable, inc_cfg *IncludeConfig, report diag.ReportFunc) []goldmark.Extender {
+	exts := []goldmark.Extender{}
+	for _, name := range mc.EnabledExtensions() {
+		factory, ok := lookupExtension(name)
+		if !ok {
+			if report != nil {
+				report(&diag.Diagnostic{
+					Severity: diag.Warning,
+					Message:  "unknown markdown extension: " + name,
+				})
+			}
+			continue
+		}
+		if ext := factory(mc, id_tbl, inc_cfg); ext != nil {
+			exts = append(exts, ext)
+		}
+	}
+
+	return exts
+}
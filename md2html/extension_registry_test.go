@@ -0,0 +1,98 @@
+package md2html
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/yuin/goldmark"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+	"github.com/1f408/cats_eeds/md2html/uniqid"
+)
+
+type testExtender struct{}
+
+func (testExtender) Extend(_ goldmark.Markdown) {}
+
+func testExtensionFactory(_ *MdConfig, _ uniqid.IdsTable, _ *IncludeConfig) goldmark.Extender {
+	return testExtender{}
+}
+
+func mustPanic(t *testing.T, name string, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: no panic", name)
+		}
+	}()
+	f()
+}
+
+// registerTestExtension keeps the tests repeatable with -count.
+func registerTestExtension(name string) {
+	if _, ok := lookupExtension(name); !ok {
+		RegisterExtension(name, testExtensionFactory)
+	}
+}
+
+func TestRegisterExtension(t *testing.T) {
+	registerTestExtension("test-register")
+	if !slices.Contains(RegisteredExtensions(), "test-register") {
+		t.Errorf("got %v", RegisteredExtensions())
+	}
+
+	mustPanic(t, "duplicate", func() { RegisterExtension("test-register", testExtensionFactory) })
+	mustPanic(t, "built-in", func() { RegisterExtension("table", testExtensionFactory) })
+	mustPanic(t, "built-in with underscore", func() { RegisterExtension("task_list", testExtensionFactory) })
+	mustPanic(t, "empty name", func() { RegisterExtension("", testExtensionFactory) })
+	mustPanic(t, "nil factory", func() { RegisterExtension("test-nil", nil) })
+}
+
+func TestEnabledExtensions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"md.conf": {Data: []byte(`extensions = ["b", "a", "off"]
+
+[extension]
+table = false
+task_list = true
+off = false
+c = true
+a = true
+`)},
+	}
+	mc, err := NewMdConfig(fsys, "/md.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mc.Extension.Table || !mc.Extension.TaskList {
+		t.Errorf("built-in flags: got %+v", mc.Extension)
+	}
+	for _, name := range []string{"table", "task_list"} {
+		if _, ok := mc.Extension.Named[name]; ok {
+			t.Errorf("built-in flag %s is named", name)
+		}
+	}
+	if got, want := mc.EnabledExtensions(), []string{"b", "a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRegisteredParserExtsUnknown(t *testing.T) {
+	registerTestExtension("test-known")
+
+	mc := &MdConfig{Extensions: []string{"test-known", "test-unknown"}}
+	diags := []*diag.Diagnostic{}
+	exts := registeredParserExts(mc, nil, nil, func(d *diag.Diagnostic) {
+		diags = append(diags, d)
+	})
+
+	if len(exts) != 1 {
+		t.Errorf("got %d extensions, want 1", len(exts))
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning ||
+		diags[0].Message != "unknown markdown extension: test-unknown" {
+		t.Errorf("got %+v", diags)
+	}
+}
@@ -238,7 +238,7 @@ func (a *footnoteASTTransformer) Transform(node *gast.Document, reader text.Read
 		index := fn.Index
 		if index < 0 {
 			list.RemoveChild(list, footnote)
-		} else {
+		} else if a.Ctx.Config.Mode != FootnoteModeSidenote {
 			refCount := counter[index]
 			backLink := ast.NewFootnoteBacklink(index)
 			backLink.RefCount = refCount
@@ -266,9 +266,114 @@ func (a *footnoteASTTransformer) Transform(node *gast.Document, reader text.Read
 		return
 	}
 
+	switch a.Ctx.Config.Mode {
+	case FootnoteModeSection:
+		list.Parent().RemoveChild(list.Parent(), list)
+		splitSectionFootnotes(node, list)
+		return
+	case FootnoteModeSidenote:
+		placeSidenotes(list, fnlist)
+	}
+
 	node.AppendChild(node, list)
 }
 
+func isSectionHeading(n gast.Node) bool {
+	h, ok := n.(*gast.Heading)
+	return ok && h.Level <= 2
+}
+
+// splitSectionFootnotes moves each footnote into a list at the end of the
+// H2 section of its first reference.
+func splitSectionFootnotes(doc *gast.Document, list *ast.FootnoteList) {
+	starts := []gast.Node{}
+	section := map[int]int{}
+	for c := doc.FirstChild(); c != nil; c = c.NextSibling() {
+		if isSectionHeading(c) {
+			starts = append(starts, c)
+		}
+		_ = gast.Walk(c, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+			if fnlink, ok := n.(*ast.FootnoteLink); ok && entering && fnlink.RefIndex == 0 {
+				section[fnlink.Index] = len(starts)
+			}
+			return gast.WalkContinue, nil
+		})
+	}
+
+	lists := make([]*ast.FootnoteList, len(starts)+1)
+	for footnote := list.FirstChild(); footnote != nil; {
+		next := footnote.NextSibling()
+		sec, ok := section[footnote.(*ast.Footnote).Index]
+		if !ok {
+			sec = len(starts)
+		}
+		if lists[sec] == nil {
+			lists[sec] = ast.NewFootnoteList()
+		}
+		lists[sec].AppendChild(lists[sec], footnote)
+		lists[sec].Count++
+		footnote = next
+	}
+
+	for sec, l := range lists {
+		if l == nil {
+			continue
+		}
+		if sec < len(starts) {
+			doc.InsertBefore(doc, starts[sec], l)
+		} else {
+			doc.AppendChild(doc, l)
+		}
+	}
+}
+
+func isSidenoteAnchor(n gast.Node) bool {
+	switch n.Kind() {
+	case gast.KindParagraph, gast.KindTextBlock, gast.KindHeading:
+		return true
+	}
+	return n.Parent() != nil && n.Parent().Kind() == gast.KindDocument
+}
+
+// placeSidenotes moves each footnote after the block of its first
+// reference. The emptied list is left as the end marker of the footnotes.
+func placeSidenotes(list *ast.FootnoteList, fnlist []*ast.FootnoteLink) {
+	first := map[int]*ast.FootnoteLink{}
+	for _, fnlink := range fnlist {
+		if fnlink.RefIndex == 0 {
+			first[fnlink.Index] = fnlink
+		}
+	}
+
+	last := map[gast.Node]gast.Node{}
+	for footnote := list.FirstChild(); footnote != nil; {
+		next := footnote.NextSibling()
+		fnlink, ok := first[footnote.(*ast.Footnote).Index]
+		if !ok {
+			footnote = next
+			continue
+		}
+
+		var anchor gast.Node = fnlink
+		for anchor.Parent() != nil && !(anchor.Type() == gast.TypeBlock && isSidenoteAnchor(anchor)) {
+			anchor = anchor.Parent()
+		}
+		if anchor.Parent() == nil {
+			footnote = next
+			continue
+		}
+
+		after := anchor
+		if l, ok := last[anchor]; ok {
+			after = l
+		}
+		list.RemoveChild(list, footnote)
+		anchor.Parent().InsertAfter(anchor.Parent(), after, footnote)
+		last[anchor] = footnote
+		footnote = next
+	}
+}
+
 // FootnoteConfig holds configuration values for the footnote extension.
 //
 // Link* and Backlink* configurations have some variables:
@@ -296,6 +401,30 @@ type FootnoteConfig struct {
 
 	// BacklinkHTML is an HTML content for footnote backlinks.
 	BacklinkHTML []byte
+
+	// Mode is the placement of footnotes.
+	Mode FootnoteMode
+}
+
+// FootnoteMode is the placement of footnotes.
+type FootnoteMode string
+
+const (
+	// FootnoteModeEnd renders all footnotes at the end of the document.
+	FootnoteModeEnd FootnoteMode = "end"
+	// FootnoteModeSection renders footnotes at the end of each H2 section.
+	FootnoteModeSection FootnoteMode = "section"
+	// FootnoteModeSidenote renders footnotes as asides next to the reference.
+	FootnoteModeSidenote FootnoteMode = "sidenote"
+)
+
+// IsValid reports whether the mode is a known footnote mode.
+func (m FootnoteMode) IsValid() bool {
+	switch m {
+	case FootnoteModeEnd, FootnoteModeSection, FootnoteModeSidenote:
+		return true
+	}
+	return false
 }
 
 // FootnoteOption interface is a functional option interface for the extension.
@@ -315,6 +444,7 @@ func NewFootnoteConfig() *FootnoteConfig {
 		LinkClass:     []byte("footnote-ref"),
 		BacklinkClass: []byte("footnote-backref"),
 		BacklinkHTML:  []byte("&#x21a9;&#xfe0e;"),
+		Mode:          FootnoteModeEnd,
 	}
 }
 
@@ -333,6 +463,8 @@ func (c *FootnoteConfig) SetOption(name renderer.OptionName, value any) {
 		c.BacklinkClass = value.([]byte)
 	case optFootnoteBacklinkHTML:
 		c.BacklinkHTML = value.([]byte)
+	case optFootnoteMode:
+		c.Mode = value.(FootnoteMode)
 	default:
 		c.Config.SetOption(name, value)
 	}
@@ -477,6 +609,25 @@ func WithFootnoteBacklinkHTML[T []byte | string](a T) FootnoteOption {
 	return &withFootnoteBacklinkHTML{[]byte(a)}
 }
 
+const optFootnoteMode renderer.OptionName = "FootnoteMode"
+
+type withFootnoteMode struct {
+	value FootnoteMode
+}
+
+func (o *withFootnoteMode) SetConfig(c *renderer.Config) {
+	c.Options[optFootnoteMode] = o.value
+}
+
+func (o *withFootnoteMode) SetFootnoteOption(c *FootnoteConfig) {
+	c.Mode = o.value
+}
+
+// WithFootnoteMode is a functional option that is the placement of footnotes.
+func WithFootnoteMode(m FootnoteMode) FootnoteOption {
+	return &withFootnoteMode{m}
+}
+
 // FootnoteHTMLRenderer is a renderer.NodeRenderer implementation that
 // renders FootnoteLink nodes.
 type FootnoteHTMLRenderer struct {
@@ -542,6 +693,9 @@ func (r *FootnoteHTMLRenderer) renderFootnoteBacklink(
 
 func (r *FootnoteHTMLRenderer) renderFootnote(
 	w util.BufWriter, source []byte, node gast.Node, entering bool) (gast.WalkStatus, error) {
+	if node.Parent().Kind() != ast.KindFootnoteList {
+		return r.renderSidenote(w, source, node, entering)
+	}
 	if entering {
 		_, _ = w.WriteString(`<li id="`)
 		_, _ = w.Write(r.getIndexId(node))
@@ -556,8 +710,42 @@ func (r *FootnoteHTMLRenderer) renderFootnote(
 	return gast.WalkContinue, nil
 }
 
+func (r *FootnoteHTMLRenderer) renderSidenote(
+	w util.BufWriter, source []byte, node gast.Node, entering bool) (gast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<aside id="`)
+		_, _ = w.Write(r.getIndexId(node))
+		_, _ = w.WriteString(`" class="footnote-sidenote" role="doc-footnote"`)
+		if node.Attributes() != nil {
+			html.RenderAttributes(w, node, html.GlobalAttributeFilter)
+		}
+		_, _ = w.WriteString(">\n")
+		_, _ = w.WriteString(`<sup class="footnote-number">`)
+		_, _ = w.WriteString(r.index_str(node))
+		_, _ = w.WriteString("</sup>\n")
+	} else {
+		_, _ = w.WriteString("</aside>\n")
+	}
+	return gast.WalkContinue, nil
+}
+
+func hasNextFootnoteList(node gast.Node) bool {
+	for n := node.NextSibling(); n != nil; n = n.NextSibling() {
+		if n.Kind() == ast.KindFootnoteList {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *FootnoteHTMLRenderer) renderFootnoteList(
 	w util.BufWriter, source []byte, node gast.Node, entering bool) (gast.WalkStatus, error) {
+	if !node.HasChildren() {
+		if !entering {
+			r.Ctx.Finish()
+		}
+		return gast.WalkContinue, nil
+	}
 	if entering {
 		_, _ = w.WriteString(`<div class="footnotes" role="doc-endnotes"`)
 		if node.Attributes() != nil {
@@ -575,7 +763,7 @@ func (r *FootnoteHTMLRenderer) renderFootnoteList(
 		_, _ = w.WriteString("</div>\n")
 	}
 
-	if !entering {
+	if !entering && !hasNextFootnoteList(node) {
 		r.Ctx.Finish()
 	}
 
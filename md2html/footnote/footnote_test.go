@@ -129,3 +129,113 @@ Expected: `<p>That's some text with a footnote.<sup id="fn-2:1-r"><a href="#fn-2
 		t,
 	)
 }
+
+func TestFootnoteModes(t *testing.T) {
+	src := `Intro.[^a]
+
+## One
+
+Text[^b] and[^c].
+
+- item[^a]
+
+## Two
+
+None.
+
+[^a]: Note A.
+[^b]: Note B.
+[^c]: Note C.
+`
+
+	markdown := goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+		goldmark.WithExtensions(
+			footnote.NewFootnote(
+				uniqid.NewMapIdsTable(),
+				footnote.WithFootnoteMode(footnote.FootnoteModeSection),
+			),
+		),
+	)
+
+	testutil.DoTestCase(
+		markdown,
+		testutil.MarkdownTestCase{
+			No:          1,
+			Description: "Footnote section mode",
+			Markdown:    src,
+Expected: `<p>Intro.<sup id="fn:1-r"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup></p>
+<div class="footnotes" role="doc-endnotes">
+<hr>
+<ol>
+<li id="fn:1">
+<p>Note A.&#160;<a href="#fn:1-r" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a>&#160;<a href="#fn:1-r-1" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a></p>
+</li>
+</ol>
+</div>
+<h2>One</h2>
+<p>Text<sup id="fn:2-r"><a href="#fn:2" class="footnote-ref" role="doc-noteref">2</a></sup> and<sup id="fn:3-r"><a href="#fn:3" class="footnote-ref" role="doc-noteref">3</a></sup>.</p>
+<ul>
+<li>item<sup id="fn:1-r-1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup></li>
+</ul>
+<div class="footnotes" role="doc-endnotes">
+<hr>
+<ol>
+<li id="fn:2">
+<p>Note B.&#160;<a href="#fn:2-r" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a></p>
+</li>
+<li id="fn:3">
+<p>Note C.&#160;<a href="#fn:3-r" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a></p>
+</li>
+</ol>
+</div>
+<h2>Two</h2>
+<p>None.</p>`,
+		},
+		t,
+	)
+
+	markdown = goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+		goldmark.WithExtensions(
+			footnote.NewFootnote(
+				uniqid.NewMapIdsTable(),
+				footnote.WithFootnoteMode(footnote.FootnoteModeSidenote),
+			),
+		),
+	)
+
+	testutil.DoTestCase(
+		markdown,
+		testutil.MarkdownTestCase{
+			No:          2,
+			Description: "Footnote sidenote mode",
+			Markdown:    src,
+Expected: `<p>Intro.<sup id="fn:1-r"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup></p>
+<aside id="fn:1" class="footnote-sidenote" role="doc-footnote">
+<sup class="footnote-number">1</sup>
+<p>Note A.</p>
+</aside>
+<h2>One</h2>
+<p>Text<sup id="fn:2-r"><a href="#fn:2" class="footnote-ref" role="doc-noteref">2</a></sup> and<sup id="fn:3-r"><a href="#fn:3" class="footnote-ref" role="doc-noteref">3</a></sup>.</p>
+<aside id="fn:2" class="footnote-sidenote" role="doc-footnote">
+<sup class="footnote-number">2</sup>
+<p>Note B.</p>
+</aside>
+<aside id="fn:3" class="footnote-sidenote" role="doc-footnote">
+<sup class="footnote-number">3</sup>
+<p>Note C.</p>
+</aside>
+<ul>
+<li>item<sup id="fn:1-r-1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup></li>
+</ul>
+<h2>Two</h2>
+<p>None.</p>`,
+		},
+		t,
+	)
+}
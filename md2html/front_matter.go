@@ -51,8 +51,18 @@ type FrontMatterParam struct {
 	SmCard      SmCardParam `yaml:"sm_card,omitempty" toml:"sm_card,omitempty" json:"sm_card,omitempty"`
 	CustomParam CustomParam `yaml:"custom_param,omitempty" toml:"custom_param,omitempty" json:"custom_param,omitempty"`
 	LinkMenu    []Link      `yaml:"link_menu,omitempty" toml:"link_menu,omitempty" json:"link_menu,omitempty"`
+	Toc         *TocParam   `yaml:"toc,omitempty" toml:"toc,omitempty" json:"toc,omitempty"`
 
-	Config *FrontMatterConfig `yaml:"-" toml:"-" json:"-"`
+	Config     *FrontMatterConfig `yaml:"-" toml:"-" json:"-"`
+	LineOffset int                `yaml:"-" toml:"-" json:"-"`
+}
+
+type TocParam struct {
+	MinLevel       *int  `yaml:"min_level,omitempty" toml:"min_level,omitempty" json:"min_level,omitempty"`
+	MaxLevel       *int  `yaml:"max_level,omitempty" toml:"max_level,omitempty" json:"max_level,omitempty"`
+	Numbered       *bool `yaml:"numbered,omitempty" toml:"numbered,omitempty" json:"numbered,omitempty"`
+	NumberHeadings *bool `yaml:"number_headings,omitempty" toml:"number_headings,omitempty" json:"number_headings,omitempty"`
+	DropTitle      *bool `yaml:"drop_title,omitempty" toml:"drop_title,omitempty" json:"drop_title,omitempty"`
 }
 
 type FrontMatterConfig struct {
@@ -108,6 +118,7 @@ func (fmc *FrontMatterConfig) TrimAndParse(bin []byte) ([]byte, *FrontMatterPara
 	}
 
 	fmp.Config = fmc
+	fmp.LineOffset = bytes.Count(bin[:len(bin)-len(body)], []byte("\n"))
 	fmp.fix()
 
 	return body, fmp, nil
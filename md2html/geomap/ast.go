@@ -0,0 +1,38 @@
+package geomap
+
+import (
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+type GeoMapBlockNode struct {
+	ast.BaseBlock
+	Data    []byte
+	Summary *Summary
+}
+
+func (n *GeoMapBlockNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Format": n.Summary.Format,
+		"Count":  strconv.Itoa(n.Summary.Count),
+	}, nil)
+}
+
+var KindGeoMapBlock = ast.NewNodeKind("GeoMapBlock")
+
+func (n *GeoMapBlockNode) Kind() ast.NodeKind {
+	return KindGeoMapBlock
+}
+
+func NewGeoMapBlockNode(data []byte, s *Summary) *GeoMapBlockNode {
+	n := &GeoMapBlockNode{
+		BaseBlock: ast.BaseBlock{},
+		Data:      data,
+		Summary:   s,
+	}
+	n.SetAttributeString("class", "markdown-geomap")
+	n.SetAttributeString("data-geomap-format", s.Format)
+	n.SetAttributeString("data-geomap", data)
+	return n
+}
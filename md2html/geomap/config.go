@@ -0,0 +1,49 @@
+package geomap
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+type Config struct {
+	Report diag.ReportFunc
+}
+
+type Option interface {
+	SetGeoMapOption(*Config)
+}
+
+type withReport struct {
+	value diag.ReportFunc
+}
+
+func (o *withReport) SetGeoMapOption(c *Config) {
+	c.Report = o.value
+}
+
+func WithReport(f diag.ReportFunc) Option {
+	return &withReport{value: f}
+}
+
+func NewGeoMap(opts ...Option) goldmark.Extender {
+	return &geoMapExtension{
+		options: opts,
+	}
+}
+
+type geoMapExtension struct {
+	options []Option
+}
+
+func (e *geoMapExtension) Extend(md goldmark.Markdown) {
+	md.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(NewGeoMapTransformer(e.options...), 500),
+	))
+	md.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(NewGeoMapRenderer(), 500),
+	))
+}
@@ -0,0 +1,223 @@
+package geomap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrBadGeoJSON = errors.New("invalid GeoJSON")
+var ErrBadTopoJSON = errors.New("invalid TopoJSON")
+
+type Summary struct {
+	Format  string
+	BBox    [4]float64
+	HasBBox bool
+	Count   int
+	Names   []string
+}
+
+func (s *Summary) extend(lon, lat float64) {
+	if !s.HasBBox {
+		s.BBox = [4]float64{lon, lat, lon, lat}
+		s.HasBBox = true
+		return
+	}
+	s.BBox[0] = math.Min(s.BBox[0], lon)
+	s.BBox[1] = math.Min(s.BBox[1], lat)
+	s.BBox[2] = math.Max(s.BBox[2], lon)
+	s.BBox[3] = math.Max(s.BBox[3], lat)
+}
+
+func (s *Summary) addName(props json.RawMessage) {
+	var p struct {
+		Name  *string `json:"name"`
+		Title *string `json:"title"`
+	}
+	if json.Unmarshal(props, &p) != nil {
+		return
+	}
+	switch {
+	case p.Name != nil && *p.Name != "":
+		s.Names = append(s.Names, *p.Name)
+	case p.Title != nil && *p.Title != "":
+		s.Names = append(s.Names, *p.Title)
+	}
+}
+
+type geoObject struct {
+	Type        string            `json:"type"`
+	Features    []json.RawMessage `json:"features"`
+	Geometry    json.RawMessage   `json:"geometry"`
+	Geometries  []json.RawMessage `json:"geometries"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Properties  json.RawMessage   `json:"properties"`
+}
+
+func geoErr(format string, v ...any) error {
+	return fmt.Errorf("%w: %s", ErrBadGeoJSON, fmt.Sprintf(format, v...))
+}
+
+func (s *Summary) position(p []float64) error {
+	if len(p) < 2 {
+		return geoErr("position needs at least 2 numbers")
+	}
+	lon, lat := p[0], p[1]
+	if lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+		return geoErr("position out of range: [%g, %g]", lon, lat)
+	}
+	s.extend(lon, lat)
+	return nil
+}
+
+func (s *Summary) positions(ps [][]float64, min int) error {
+	if len(ps) < min {
+		return geoErr("needs at least %d positions", min)
+	}
+	for _, p := range ps {
+		if err := s.position(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Summary) ring(ps [][]float64) error {
+	if err := s.positions(ps, 4); err != nil {
+		return err
+	}
+	first, last := ps[0], ps[len(ps)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		return geoErr("polygon ring is not closed")
+	}
+	return nil
+}
+
+func (s *Summary) coordinates(typ string, raw json.RawMessage) error {
+	var err error
+	switch typ {
+	case "Point":
+		var p []float64
+		if err = json.Unmarshal(raw, &p); err == nil {
+			err = s.position(p)
+		}
+	case "MultiPoint":
+		var ps [][]float64
+		if err = json.Unmarshal(raw, &ps); err == nil {
+			err = s.positions(ps, 0)
+		}
+	case "LineString":
+		var ps [][]float64
+		if err = json.Unmarshal(raw, &ps); err == nil {
+			err = s.positions(ps, 2)
+		}
+	case "MultiLineString":
+		var ls [][][]float64
+		if err = json.Unmarshal(raw, &ls); err == nil {
+			for _, ps := range ls {
+				if err = s.positions(ps, 2); err != nil {
+					break
+				}
+			}
+		}
+	case "Polygon":
+		var rs [][][]float64
+		if err = json.Unmarshal(raw, &rs); err == nil {
+			for _, ps := range rs {
+				if err = s.ring(ps); err != nil {
+					break
+				}
+			}
+		}
+	case "MultiPolygon":
+		var pls [][][][]float64
+		if err = json.Unmarshal(raw, &pls); err == nil {
+		loop:
+			for _, rs := range pls {
+				for _, ps := range rs {
+					if err = s.ring(ps); err != nil {
+						break loop
+					}
+				}
+			}
+		}
+	default:
+		return geoErr("unknown geometry type: %q", typ)
+	}
+
+	if err != nil && !errors.Is(err, ErrBadGeoJSON) {
+		return geoErr("%s coordinates: %v", typ, err)
+	}
+	return err
+}
+
+func (s *Summary) geometry(raw json.RawMessage) error {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+
+	var g geoObject
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return geoErr("%v", err)
+	}
+	if g.Type == "GeometryCollection" {
+		for _, sub := range g.Geometries {
+			if err := s.geometry(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if g.Coordinates == nil {
+		return geoErr("%s without coordinates", g.Type)
+	}
+
+	return s.coordinates(g.Type, g.Coordinates)
+}
+
+func (s *Summary) feature(raw json.RawMessage) error {
+	var f geoObject
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return geoErr("%v", err)
+	}
+	if f.Type != "Feature" {
+		return geoErr("expected Feature, got %q", f.Type)
+	}
+
+	s.Count++
+	s.addName(f.Properties)
+	return s.geometry(f.Geometry)
+}
+
+// ParseGeoJSON validates a GeoJSON document and summarizes it.
+func ParseGeoJSON(src []byte) (*Summary, error) {
+	var obj geoObject
+	if err := json.Unmarshal(src, &obj); err != nil {
+		return nil, geoErr("%v", err)
+	}
+
+	s := &Summary{Format: "geojson", Names: []string{}}
+	switch obj.Type {
+	case "FeatureCollection":
+		for _, f := range obj.Features {
+			if err := s.feature(f); err != nil {
+				return nil, err
+			}
+		}
+	case "Feature":
+		if err := s.feature(src); err != nil {
+			return nil, err
+		}
+	case "":
+		return nil, geoErr("missing type")
+	default:
+		s.Count = 1
+		if err := s.geometry(src); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,92 @@
+package geomap
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/yuin/goldmark"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+func TestGeoMap(t *testing.T) {
+	diags := []*diag.Diagnostic{}
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewGeoMap(
+				WithReport(func(d *diag.Diagnostic) { diags = append(diags, d) }),
+			),
+		),
+	)
+
+	src := []byte("```geojson\n" +
+		`{"type": "FeatureCollection", "features": [
+  {"type": "Feature", "properties": {"name": "A&B"}, "geometry": {"type": "Point", "coordinates": [139.7, 35.6]}},
+  {"type": "Feature", "properties": {}, "geometry": {"type": "LineString", "coordinates": [[135.5, 34.7], [140, 36]]}}
+]}` + "\n```\n\n" +
+		"```map\n{\"type\": \"Point\", \"coordinates\": [200, 0]}\n```\n")
+	var buf bytes.Buffer
+	if err := markdown.Convert(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<div class="markdown-geomap" data-geomap-format="geojson" data-geomap="{&quot;type&quot;:&quot;FeatureCollection&quot;,&quot;features&quot;:[{&quot;type&quot;:&quot;Feature&quot;,&quot;properties&quot;:{&quot;name&quot;:&quot;A&amp;B&quot;},&quot;geometry&quot;:{&quot;type&quot;:&quot;Point&quot;,&quot;coordinates&quot;:[139.7,35.6]}},{&quot;type&quot;:&quot;Feature&quot;,&quot;properties&quot;:{},&quot;geometry&quot;:{&quot;type&quot;:&quot;LineString&quot;,&quot;coordinates&quot;:[[135.5,34.7],[140,36]]}}]}">
+<p class="markdown-geomap_summary">2 features, bbox: [135.5, 34.7, 140, 36]</p>
+<ul class="markdown-geomap_features">
+<li>A&amp;B</li>
+</ul>
+</div>
+<pre><code class="language-map">{&quot;type&quot;: &quot;Point&quot;, &quot;coordinates&quot;: [200, 0]}
+</code></pre>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(diags) != 1 || diags[0].Line != 8 {
+		t.Errorf("got diagnostics %v", diags)
+	}
+}
+
+func TestParseTopoJSON(t *testing.T) {
+	src := []byte(`{"type": "Topology",
+  "transform": {"scale": [0.5, 0.5], "translate": [100, 10]},
+  "objects": {"areas": {"type": "GeometryCollection", "geometries": [
+    {"type": "Polygon", "arcs": [[0]], "properties": {"name": "square"}},
+    {"type": "Point", "coordinates": [4, 4], "properties": {"title": "pin"}}
+  ]}},
+  "arcs": [[[0, 0], [2, 0], [0, 2], [-2, 0], [0, -2]]]}`)
+
+	s, err := Parse("map", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Format != "topojson" || s.Count != 2 {
+		t.Errorf("got format %q count %d", s.Format, s.Count)
+	}
+	if s.BBox != [4]float64{100, 10, 102, 12} {
+		t.Errorf("got bbox %v", s.BBox)
+	}
+	if len(s.Names) != 2 || s.Names[0] != "square" || s.Names[1] != "pin" {
+		t.Errorf("got names %v", s.Names)
+	}
+
+	bad := []byte(`{"type": "Topology", "objects": {"a": {"type": "LineString", "arcs": [3]}}, "arcs": []}`)
+	if _, err := ParseTopoJSON(bad); !errors.Is(err, ErrBadTopoJSON) {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestParseGeoJSONError(t *testing.T) {
+	tests := []string{
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}`,
+		`{"type": "Feature", "geometry": {"type": "Circle", "coordinates": [0, 0]}}`,
+		`{"features": []}`,
+		`[1, 2]`,
+	}
+	for _, src := range tests {
+		if _, err := ParseGeoJSON([]byte(src)); !errors.Is(err, ErrBadGeoJSON) {
+			t.Errorf("%s: got error %v", src, err)
+		}
+	}
+}
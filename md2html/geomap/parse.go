@@ -0,0 +1,37 @@
+package geomap
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Parse validates a map block. The "map" language is detected from the
+// top-level type member.
+func Parse(lang string, src []byte) (*Summary, error) {
+	switch lang {
+	case "geojson":
+		return ParseGeoJSON(src)
+	case "topojson":
+		return ParseTopoJSON(src)
+	}
+
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(src, &head); err != nil {
+		return nil, geoErr("%v", err)
+	}
+	if head.Type == "Topology" {
+		return ParseTopoJSON(src)
+	}
+	return ParseGeoJSON(src)
+}
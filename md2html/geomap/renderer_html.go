@@ -0,0 +1,75 @@
+package geomap
+
+import (
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+var GeoMapBlockAttributeFilter = html.GlobalAttributeFilter.Extend(
+	[]byte("data-geomap"),
+	[]byte("data-geomap-format"),
+)
+
+type geoMapRenderer struct{}
+
+func NewGeoMapRenderer() renderer.NodeRenderer {
+	return &geoMapRenderer{}
+}
+
+func (r *geoMapRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindGeoMapBlock, r.renderGeoMapBlock)
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (r *geoMapRenderer) renderGeoMapBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*GeoMapBlockNode)
+	s := n.Summary
+
+	_, _ = w.WriteString("<div")
+	html.RenderAttributes(w, n, GeoMapBlockAttributeFilter)
+	_, _ = w.WriteString(">\n")
+
+	_, _ = w.WriteString(`<p class="markdown-geomap_summary">`)
+	_, _ = w.WriteString(strconv.Itoa(s.Count))
+	if s.Count == 1 {
+		_, _ = w.WriteString(" feature")
+	} else {
+		_, _ = w.WriteString(" features")
+	}
+	if s.HasBBox {
+		_, _ = w.WriteString(", bbox: [")
+		for i, v := range s.BBox {
+			if i > 0 {
+				_, _ = w.WriteString(", ")
+			}
+			_, _ = w.WriteString(formatCoord(v))
+		}
+		_ = w.WriteByte(']')
+	}
+	_, _ = w.WriteString("</p>\n")
+
+	if len(s.Names) > 0 {
+		_, _ = w.WriteString("<ul class=\"markdown-geomap_features\">\n")
+		for _, name := range s.Names {
+			_, _ = w.WriteString("<li>")
+			_, _ = w.Write(util.EscapeHTML([]byte(name)))
+			_, _ = w.WriteString("</li>\n")
+		}
+		_, _ = w.WriteString("</ul>\n")
+	}
+
+	_, _ = w.WriteString("</div>\n")
+
+	return ast.WalkSkipChildren, nil
+}
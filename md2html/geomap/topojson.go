@@ -0,0 +1,209 @@
+package geomap
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type topoTransform struct {
+	Scale     [2]float64 `json:"scale"`
+	Translate [2]float64 `json:"translate"`
+}
+
+type topology struct {
+	Type      string                     `json:"type"`
+	Objects   map[string]json.RawMessage `json:"objects"`
+	Arcs      [][][]float64              `json:"arcs"`
+	Transform *topoTransform             `json:"transform"`
+	BBox      []float64                  `json:"bbox"`
+}
+
+type topoObject struct {
+	Type        string            `json:"type"`
+	Geometries  []json.RawMessage `json:"geometries"`
+	Arcs        json.RawMessage   `json:"arcs"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Properties  json.RawMessage   `json:"properties"`
+}
+
+func topoErr(format string, v ...any) error {
+	return fmt.Errorf("%w: %s", ErrBadTopoJSON, fmt.Sprintf(format, v...))
+}
+
+type topoParser struct {
+	s    *Summary
+	topo *topology
+}
+
+func (p *topoParser) point(pt []float64) error {
+	if len(pt) < 2 {
+		return topoErr("position needs at least 2 numbers")
+	}
+	x, y := pt[0], pt[1]
+	if tf := p.topo.Transform; tf != nil {
+		x = x*tf.Scale[0] + tf.Translate[0]
+		y = y*tf.Scale[1] + tf.Translate[1]
+	}
+	p.s.extend(x, y)
+	return nil
+}
+
+func (p *topoParser) arcs() error {
+	for i, arc := range p.topo.Arcs {
+		if len(arc) < 2 {
+			return topoErr("arc %d needs at least 2 positions", i)
+		}
+
+		var x, y float64
+		for _, pt := range arc {
+			if len(pt) < 2 {
+				return topoErr("arc %d: position needs at least 2 numbers", i)
+			}
+			if p.topo.Transform == nil {
+				p.s.extend(pt[0], pt[1])
+				continue
+			}
+			x += pt[0]
+			y += pt[1]
+			p.point([]float64{x, y})
+		}
+	}
+	return nil
+}
+
+func (p *topoParser) arcIndexes(idxs []int) error {
+	for _, i := range idxs {
+		if i < 0 {
+			i = ^i
+		}
+		if i >= len(p.topo.Arcs) {
+			return topoErr("arc index out of range: %d", i)
+		}
+	}
+	return nil
+}
+
+func (p *topoParser) geometryArcs(typ string, raw json.RawMessage) error {
+	var err error
+	switch typ {
+	case "LineString":
+		var a []int
+		if err = json.Unmarshal(raw, &a); err == nil {
+			err = p.arcIndexes(a)
+		}
+	case "MultiLineString", "Polygon":
+		var a [][]int
+		if err = json.Unmarshal(raw, &a); err == nil {
+			for _, idxs := range a {
+				if err = p.arcIndexes(idxs); err != nil {
+					break
+				}
+			}
+		}
+	case "MultiPolygon":
+		var a [][][]int
+		if err = json.Unmarshal(raw, &a); err == nil {
+		loop:
+			for _, rs := range a {
+				for _, idxs := range rs {
+					if err = p.arcIndexes(idxs); err != nil {
+						break loop
+					}
+				}
+			}
+		}
+	}
+	if err != nil {
+		return topoErr("%s arcs: %v", typ, err)
+	}
+	return nil
+}
+
+func (p *topoParser) geometry(raw json.RawMessage, top bool) error {
+	var g topoObject
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return topoErr("%v", err)
+	}
+
+	switch g.Type {
+	case "GeometryCollection":
+		for _, sub := range g.Geometries {
+			if err := p.geometry(sub, false); err != nil {
+				return err
+			}
+		}
+		if !top {
+			p.s.Count++
+			p.s.addName(g.Properties)
+		}
+		return nil
+	case "Point":
+		var pt []float64
+		if err := json.Unmarshal(g.Coordinates, &pt); err != nil {
+			return topoErr("Point coordinates: %v", err)
+		}
+		if err := p.point(pt); err != nil {
+			return err
+		}
+	case "MultiPoint":
+		var pts [][]float64
+		if err := json.Unmarshal(g.Coordinates, &pts); err != nil {
+			return topoErr("MultiPoint coordinates: %v", err)
+		}
+		for _, pt := range pts {
+			if err := p.point(pt); err != nil {
+				return err
+			}
+		}
+	case "LineString", "MultiLineString", "Polygon", "MultiPolygon":
+		if g.Arcs == nil {
+			return topoErr("%s without arcs", g.Type)
+		}
+		if err := p.geometryArcs(g.Type, g.Arcs); err != nil {
+			return err
+		}
+	case "":
+		// null geometry
+	default:
+		return topoErr("unknown geometry type: %q", g.Type)
+	}
+
+	p.s.Count++
+	p.s.addName(g.Properties)
+	return nil
+}
+
+// ParseTopoJSON validates a TopoJSON topology and summarizes it.
+func ParseTopoJSON(src []byte) (*Summary, error) {
+	topo := &topology{}
+	if err := json.Unmarshal(src, topo); err != nil {
+		return nil, topoErr("%v", err)
+	}
+	if topo.Type != "Topology" {
+		return nil, topoErr("expected Topology, got %q", topo.Type)
+	}
+	if len(topo.Objects) == 0 {
+		return nil, topoErr("no objects")
+	}
+
+	p := &topoParser{
+		s:    &Summary{Format: "topojson", Names: []string{}},
+		topo: topo,
+	}
+	if err := p.arcs(); err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(topo.Objects) {
+		if err := p.geometry(topo.Objects[name], true); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(topo.BBox) >= 4 {
+		n := len(topo.BBox) / 2
+		p.s.BBox = [4]float64{topo.BBox[0], topo.BBox[1], topo.BBox[n], topo.BBox[n+1]}
+		p.s.HasBBox = true
+	}
+
+	return p.s, nil
+}
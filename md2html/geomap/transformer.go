@@ -0,0 +1,86 @@
+package geomap
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+var geoMapLangs = map[string]struct{}{
+	"geojson":  {},
+	"topojson": {},
+	"map":      {},
+}
+
+type geoMapTransformer struct {
+	Config
+}
+
+func NewGeoMapTransformer(opts ...Option) parser.ASTTransformer {
+	t := &geoMapTransformer{}
+	for _, opt := range opts {
+		opt.SetGeoMapOption(&t.Config)
+	}
+
+	return t
+}
+
+func (t *geoMapTransformer) report(src []byte, n ast.Node, err error) {
+	if t.Report == nil {
+		return
+	}
+
+	d := &diag.Diagnostic{Severity: diag.Warning, Message: err.Error()}
+	if n.Lines().Len() > 0 {
+		d.Line, _ = diag.Position(src, n.Lines().At(0).Start)
+		d.Line--
+		d.Column = 1
+	}
+	t.Report(d)
+}
+
+func (t *geoMapTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	src := reader.Source()
+
+	blocks := []*ast.FencedCodeBlock{}
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if fc, ok := n.(*ast.FencedCodeBlock); ok {
+			if _, ok := geoMapLangs[string(fc.Language(src))]; ok {
+				blocks = append(blocks, fc)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, fc := range blocks {
+		var code bytes.Buffer
+		lines := fc.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			code.Write(seg.Value(src))
+		}
+
+		s, err := Parse(string(fc.Language(src)), code.Bytes())
+		if err != nil {
+			t.report(src, fc, err)
+			continue
+		}
+
+		var data bytes.Buffer
+		if err := json.Compact(&data, code.Bytes()); err != nil {
+			t.report(src, fc, err)
+			continue
+		}
+
+		gn := NewGeoMapBlockNode(data.Bytes(), s)
+		fc.Parent().ReplaceChild(fc.Parent(), fc, gn)
+	}
+}
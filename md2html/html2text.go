@@ -1,9 +1,11 @@
 package md2html
 
 import (
+	"bytes"
 	"io"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 func convertHtmlNodeText(e *html.Node, w io.Writer) error {
@@ -20,3 +22,62 @@ func convertHtmlNodeText(e *html.Node, w io.Writer) error {
 	}
 	return nil
 }
+
+var htmlBlockAtoms = map[atom.Atom]struct{}{
+	atom.Address: {}, atom.Article: {}, atom.Aside: {}, atom.Blockquote: {},
+	atom.Br: {}, atom.Dd: {}, atom.Details: {}, atom.Div: {}, atom.Dl: {},
+	atom.Dt: {}, atom.Figcaption: {}, atom.Figure: {}, atom.Footer: {},
+	atom.H1: {}, atom.H2: {}, atom.H3: {}, atom.H4: {}, atom.H5: {}, atom.H6: {},
+	atom.Header: {}, atom.Hr: {}, atom.Li: {}, atom.Ol: {}, atom.P: {},
+	atom.Pre: {}, atom.Section: {}, atom.Summary: {}, atom.Table: {},
+	atom.Tbody: {}, atom.Td: {}, atom.Tfoot: {}, atom.Th: {}, atom.Thead: {},
+	atom.Tr: {}, atom.Ul: {}, atom.Caption: {},
+}
+
+func hasHtmlBlockChild(e *html.Node) bool {
+	for c := e.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if _, ok := htmlBlockAtoms[c.DataAtom]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func convertHtmlBlockText(e *html.Node, w io.Writer) error {
+	for c := e.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.ElementNode &&
+			(c.DataAtom == atom.Script || c.DataAtom == atom.Style):
+			continue
+		case c.Type == html.ElementNode && hasHtmlBlockChild(c):
+			if err := convertHtmlBlockText(c, w); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := convertHtmlNodeText(c, w); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ConvertHtmlText(html_bin []byte) (string, error) {
+	root, err := html.Parse(bytes.NewReader(html_bin))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := convertHtmlBlockText(root, &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
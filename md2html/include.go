@@ -1,27 +1,48 @@
 package md2html
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/l4go/rpath"
 	"github.com/l4go/unifs"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
 
 	"github.com/1f408/cats_eeds/frontmatter"
 	"github.com/1f408/cats_eeds/internal/ftype"
+	"github.com/1f408/cats_eeds/md2html/diag"
+	"github.com/1f408/cats_eeds/md2html/ms_include"
+	"github.com/1f408/cats_eeds/md2html/uniqid"
 )
 
 var ErrNoFMParam = errors.New("not found")
 var ErrNotMarkdown = errors.New("not markdown")
+var ErrNoSection = errors.New("not found section")
+var ErrNotLocalFile = errors.New("not local file")
+var ErrOutsideDocumentRoot = errors.New("outside document root")
+var ErrNotSourceFile = errors.New("not includable source file")
 
 type ConvertFuncParam struct {
-	Md2Html     *Md2Html
-	SystemFS    fs.FS
-	FrontMatter FrontMatterConfig
+	Md2Html      *Md2Html
+	SystemFS     fs.FS
+	FrontMatter  FrontMatterConfig
+	DocumentRoot string
 }
 
 func (cf *ConvertFuncParam) ConvertHtml(fs_file string, w io.Writer) error {
+	return cf.PartConvertHtml(fs_file, nil, w)
+}
+
+func (cf *ConvertFuncParam) PartConvertHtml(fs_file string, sel *ms_include.Selector, w io.Writer) error {
 	var err error
 	fs_file, err = unifs.Clean(fs_file)
 	if err != nil {
@@ -29,21 +50,40 @@ func (cf *ConvertFuncParam) ConvertHtml(fs_file string, w io.Writer) error {
 	}
 	fs_dir := rpath.Dir(fs_file)
 
-	if kind, _ := ftype.GetFileKindByExt(rpath.Ext(fs_file)); kind != "text/markdown" {
-		return ErrNotMarkdown
-	}
-
 	sysfs := cf.SystemFS
 	fm := cf.FrontMatter
 
 	m2h := cf.Md2Html
 
+	is_md := false
+	if kind, _ := ftype.GetFileKindByExt(rpath.Ext(fs_file)); kind == "text/markdown" {
+		is_md = true
+	}
+	if !is_md {
+		if !cf.inDocumentRoot(fs_file) {
+			return ErrOutsideDocumentRoot
+		}
+		if !m2h.isSourceExt(rpath.Ext(fs_file)) {
+			return ErrNotSourceFile
+		}
+	}
+
 	raw_bin, rd_err := unifs.ReadFile(sysfs, fs_file)
 	if rd_err != nil {
 		return rd_err
 	}
+	if lim, ok := m2h.inc_cfg.PathStack.(includeLimiter); ok {
+		if err := lim.AddBytes(len(raw_bin)); err != nil {
+			return err
+		}
+	}
+
+	if !is_md {
+		return m2h.convertSourceHtml(fs_file, raw_bin, sel, w)
+	}
 
 	md_doc := raw_bin
+	line_off := 0
 	if fm.IsEnabled() {
 		body, fm_param, fm_err := fm.TrimAndParse(raw_bin)
 		switch fm_err {
@@ -52,6 +92,7 @@ func (cf *ConvertFuncParam) ConvertHtml(fs_file string, w io.Writer) error {
 				panic("nil FrontMatterParam")
 			}
 			md_doc = body
+			line_off = fm_param.LineOffset
 		case frontmatter.ErrNotFound:
 		default:
 			return fm_err
@@ -62,13 +103,255 @@ func (cf *ConvertFuncParam) ConvertHtml(fs_file string, w io.Writer) error {
 			if name[0] != '/' {
 				name = rpath.Join(fs_dir, name)
 			}
-			if md_cfg, err := NewMdConfig(sysfs, name); err == nil {
+			name = rpath.Clean(name)
+			if !cf.inDocumentRoot(name) {
+				m2h.Report(&diag.Diagnostic{
+					Severity: diag.Warning,
+					File:     fs_file,
+					Message:  "markdown_config outside document root: " + fm_param.MarkdownConfig,
+				})
+			} else if md_cfg, err := NewMdConfig(sysfs, name); err == nil {
 				m2h = m2h.NewLocalSpec(md_cfg)
+			} else {
+				m2h.Report(&diag.Diagnostic{
+					Severity: diag.Warning,
+					File:     fs_file,
+					Message:  "markdown_config load error: " + err.Error(),
+				})
 			}
 		}
 	}
 
+	if !sel.IsZero() {
+		var part []byte
+		switch {
+		case sel.Section != "":
+			part, err = m2h.selectSection(md_doc, sel.Section)
+			line_off += lineOffset(md_doc, part)
+		case sel.LineStart > 0:
+			// line numbers are counted in the raw file, including the front matter.
+			part, err = sel.SelectText(raw_bin)
+			line_off = sel.LineStart - 1
+		default:
+			part, err = sel.SelectText(md_doc)
+			line_off += lineOffset(md_doc, part)
+		}
+		if err != nil {
+			return err
+		}
+		md_doc = part
+	}
+
+	prev_off := m2h.diags.SetLineOffset(fs_file, line_off)
+	defer m2h.diags.SetLineOffset(fs_file, prev_off)
+
 	doc_bin := m2h.md2html(md_doc)
 	_, werr := w.Write(doc_bin)
 	return werr
 }
+
+// lineOffset returns the number of the lines in front of part in src.
+func lineOffset(src []byte, part []byte) int {
+	i := bytes.Index(src, part)
+	if i <= 0 {
+		return 0
+	}
+	return bytes.Count(src[:i], []byte("\n"))
+}
+
+func (cf *ConvertFuncParam) inDocumentRoot(fs_file string) bool {
+	if cf.DocumentRoot == "" {
+		return false
+	}
+
+	return strings.HasPrefix(fs_file, rpath.SetDir(cf.DocumentRoot))
+}
+
+var urlSchemeRegexp = regexp.MustCompile(`^[^/:]+:`)
+
+func (cf *ConvertFuncParam) localFile(link string) (string, error) {
+	if link == "" || urlSchemeRegexp.MatchString(link) {
+		return "", ErrNotLocalFile
+	}
+
+	fs_file := link
+	if fs_file[0] != '/' {
+		fs_file = rpath.Join(cf.Md2Html.inc_cfg.PathStack.Cwd(), fs_file)
+	}
+	fs_file, err := unifs.Clean(fs_file)
+	if err != nil {
+		return "", err
+	}
+	if !cf.inDocumentRoot(fs_file) {
+		return "", ErrOutsideDocumentRoot
+	}
+
+	return fs_file, nil
+}
+
+func (cf *ConvertFuncParam) LoadTable(link string) ([]byte, error) {
+	fs_file, err := cf.localFile(link)
+	if err != nil {
+		return nil, err
+	}
+
+	m2h := cf.Md2Html
+	bin, err := unifs.ReadFile(cf.SystemFS, fs_file)
+	if err != nil {
+		return nil, err
+	}
+	if lim, ok := m2h.inc_cfg.PathStack.(includeLimiter); ok {
+		if err := lim.AddBytes(len(bin)); err != nil {
+			return nil, err
+		}
+	}
+
+	return bin, nil
+}
+
+func (cf *ConvertFuncParam) ListMedia(link string) ([]string, error) {
+	fs_file, err := cf.localFile(link)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := unifs.Stat(cf.SystemFS, fs_file)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, ErrNotLocalFile
+	}
+
+	ents, err := unifs.ReadDir(cf.SystemFS, rpath.Dir(fs_file))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(ents))
+	for _, e := range ents {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func (m2h *Md2Html) isSourceExt(ext string) bool {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if ext == "" {
+		return false
+	}
+
+	return slices.Contains(m2h.cfg.MsInclude.SourceExts, ext)
+}
+
+func isTextSource(src []byte) bool {
+	return utf8.Valid(src) && bytes.IndexByte(src, 0) < 0
+}
+
+func codeFence(src []byte) string {
+	max_run := 0
+	run := 0
+	for _, c := range src {
+		if c == '`' {
+			run++
+			if run > max_run {
+				max_run = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if max_run < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", max_run+1)
+}
+
+func (m2h *Md2Html) convertSourceHtml(fs_file string, src []byte, sel *ms_include.Selector, w io.Writer) error {
+	if !isTextSource(src) {
+		return ErrNotMarkdown
+	}
+	if sel != nil && sel.Section != "" {
+		return ErrNoSection
+	}
+
+	src, err := sel.SelectText(src)
+	if err != nil {
+		return err
+	}
+
+	lang := strings.ToLower(strings.TrimPrefix(rpath.Ext(fs_file), "."))
+	fence := codeFence(src)
+
+	var md bytes.Buffer
+	md.WriteString(fence + lang + "\n")
+	md.Write(src)
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		md.WriteByte('\n')
+	}
+	md.WriteString(fence + "\n")
+
+	_, werr := w.Write(m2h.md2html(md.Bytes()))
+	return werr
+}
+
+func lineStartOffset(src []byte, pos int) int {
+	if i := bytes.LastIndexByte(src[:pos], '\n'); i >= 0 {
+		return i + 1
+	}
+	return 0
+}
+
+func (m2h *Md2Html) selectSection(md []byte, id string) ([]byte, error) {
+	id_tbl := uniqid.NewMapIdsTable()
+	for _, sid := range m2h.sys_ids {
+		id_tbl.Put([]byte(sid))
+	}
+
+	head_parser := goldmark.New(
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithAttribute(),
+		),
+	)
+	new_ids, err := NewAutoIds(head_parser, m2h.cfg.AutoIds.Type, id_tbl)
+	if err != nil {
+		return nil, err
+	}
+	ctx := parser.NewContext(parser.WithIDs(new_ids))
+	doc := head_parser.Parser().Parse(text.NewReader(md), parser.WithContext(ctx))
+
+	start := -1
+	end := len(md)
+	level := 0
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		h, ok := n.(*ast.Heading)
+		if !ok || h.Lines().Len() == 0 {
+			continue
+		}
+
+		if start < 0 {
+			hid, ok := h.AttributeString("id")
+			if !ok {
+				continue
+			}
+			if b, ok := hid.([]byte); ok && string(b) == id {
+				start = lineStartOffset(md, h.Lines().At(0).Start)
+				level = h.Level
+			}
+			continue
+		}
+
+		if h.Level <= level {
+			end = lineStartOffset(md, h.Lines().At(0).Start)
+			break
+		}
+	}
+	if start < 0 {
+		return nil, ErrNoSection
+	}
+
+	return md[start:end], nil
+}
@@ -0,0 +1,176 @@
+package md2html
+
+import (
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/1f408/cats_eeds/md2html/ms_include"
+)
+
+type IncludeEdge = ms_include.Edge
+
+type IncludeGraph struct {
+	mtx         sync.RWMutex
+	includes    map[string][]string
+	included_by map[string][]string
+}
+
+type IncludeGraphNode struct {
+	Includes   []string `json:"includes"`
+	IncludedBy []string `json:"included_by"`
+}
+
+func NewIncludeGraph() *IncludeGraph {
+	return &IncludeGraph{
+		includes:    map[string][]string{},
+		included_by: map[string][]string{},
+	}
+}
+
+func (g *IncludeGraph) setIncludes(file string, children []string) {
+	for _, c := range g.includes[file] {
+		parents := slices.DeleteFunc(g.included_by[c], func(p string) bool {
+			return p == file
+		})
+		if len(parents) == 0 {
+			delete(g.included_by, c)
+		} else {
+			g.included_by[c] = parents
+		}
+	}
+
+	if len(children) == 0 {
+		delete(g.includes, file)
+		return
+	}
+
+	g.includes[file] = children
+	for _, c := range children {
+		if !slices.Contains(g.included_by[c], file) {
+			g.included_by[c] = append(g.included_by[c], file)
+		}
+	}
+}
+
+func (g *IncludeGraph) addIncludes(file string, children []string) {
+	for _, c := range children {
+		if !slices.Contains(g.includes[file], c) {
+			g.includes[file] = append(g.includes[file], c)
+		}
+		if !slices.Contains(g.included_by[c], file) {
+			g.included_by[c] = append(g.included_by[c], file)
+		}
+	}
+}
+
+func (g *IncludeGraph) SetIncludes(file string, children []string) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.setIncludes(file, slices.Clone(children))
+}
+
+// Update replaces the includes of start and of the traversed files, which
+// were included and converted as a whole. The edges of the other files are
+// seen only partly, by a section include or a stopped traversal, so they
+// are merged into the known includes.
+func (g *IncludeGraph) Update(start string, edges []IncludeEdge, traversed []string) {
+	full := map[string]struct{}{start: {}}
+	tbl := map[string][]string{start: nil}
+	for _, f := range traversed {
+		full[f] = struct{}{}
+		tbl[f] = nil
+	}
+	for _, e := range edges {
+		tbl[e.From] = append(tbl[e.From], e.To)
+	}
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	for file, children := range tbl {
+		if _, ok := full[file]; ok {
+			g.setIncludes(file, children)
+		} else {
+			g.addIncludes(file, children)
+		}
+	}
+}
+
+func (g *IncludeGraph) Remove(file string) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.setIncludes(file, nil)
+}
+
+func sortedClone(lst []string) []string {
+	lst = slices.Clone(lst)
+	if lst == nil {
+		lst = []string{}
+	}
+	sort.Strings(lst)
+	return lst
+}
+
+func (g *IncludeGraph) Includes(file string) []string {
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+
+	return sortedClone(g.includes[file])
+}
+
+func (g *IncludeGraph) IncludedBy(file string) []string {
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+
+	return sortedClone(g.included_by[file])
+}
+
+func (g *IncludeGraph) Dependents(file string) []string {
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+
+	seen := map[string]struct{}{file: {}}
+	queue := []string{file}
+	deps := []string{}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, p := range g.included_by[cur] {
+			if _, ok := seen[p]; ok {
+				continue
+			}
+			seen[p] = struct{}{}
+			deps = append(deps, p)
+			queue = append(queue, p)
+		}
+	}
+
+	sort.Strings(deps)
+	return deps
+}
+
+func (g *IncludeGraph) Nodes() map[string]*IncludeGraphNode {
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+
+	nodes := map[string]*IncludeGraphNode{}
+	get := func(f string) *IncludeGraphNode {
+		n, ok := nodes[f]
+		if !ok {
+			n = &IncludeGraphNode{Includes: []string{}, IncludedBy: []string{}}
+			nodes[f] = n
+		}
+		return n
+	}
+	for f, lst := range g.includes {
+		get(f).Includes = sortedClone(lst)
+	}
+	for f, lst := range g.included_by {
+		get(f).IncludedBy = sortedClone(lst)
+	}
+
+	return nodes
+}
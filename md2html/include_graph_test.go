@@ -0,0 +1,53 @@
+package md2html
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIncludeGraphUpdate(t *testing.T) {
+	g := NewIncludeGraph()
+	g.Update("/doc/a.md", []IncludeEdge{
+		{From: "/doc/a.md", To: "/doc/b.md"},
+		{From: "/doc/b.md", To: "/doc/c.md"},
+		{From: "/doc/b.md", To: "/doc/d.md"},
+	}, []string{"/doc/b.md", "/doc/c.md", "/doc/d.md"})
+
+	if got := g.Includes("/doc/b.md"); !slices.Equal(got, []string{"/doc/c.md", "/doc/d.md"}) {
+		t.Errorf("includes of b: got %v", got)
+	}
+	if got := g.Dependents("/doc/d.md"); !slices.Equal(got, []string{"/doc/a.md", "/doc/b.md"}) {
+		t.Errorf("dependents of d: got %v", got)
+	}
+
+	// e includes only a section of b, so the edges of b are merged.
+	g.Update("/doc/e.md", []IncludeEdge{
+		{From: "/doc/e.md", To: "/doc/b.md"},
+		{From: "/doc/b.md", To: "/doc/c.md"},
+	}, nil)
+	if got := g.Includes("/doc/b.md"); !slices.Equal(got, []string{"/doc/c.md", "/doc/d.md"}) {
+		t.Errorf("includes of b after a section include: got %v", got)
+	}
+	if got := g.Dependents("/doc/d.md"); !slices.Equal(got, []string{"/doc/a.md", "/doc/b.md", "/doc/e.md"}) {
+		t.Errorf("dependents of d after a section include: got %v", got)
+	}
+
+	// b is converted as a whole without d, so the edge to d is removed.
+	g.Update("/doc/b.md", []IncludeEdge{
+		{From: "/doc/b.md", To: "/doc/c.md"},
+	}, []string{"/doc/c.md"})
+	if got := g.Includes("/doc/b.md"); !slices.Equal(got, []string{"/doc/c.md"}) {
+		t.Errorf("includes of b after a full conversion: got %v", got)
+	}
+	if got := g.IncludedBy("/doc/d.md"); len(got) != 0 {
+		t.Errorf("included by of d: got %v", got)
+	}
+
+	g.Remove("/doc/a.md")
+	if got := g.IncludedBy("/doc/b.md"); !slices.Equal(got, []string{"/doc/e.md"}) {
+		t.Errorf("included by of b after the remove: got %v", got)
+	}
+	if _, ok := g.Nodes()["/doc/a.md"]; ok {
+		t.Error("a remains in the nodes")
+	}
+}
@@ -0,0 +1,57 @@
+package md2html
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/1f408/cats_eeds/md2html/ms_include"
+)
+
+func testConvertFuncParam(root string) *ConvertFuncParam {
+	fsys := fstest.MapFS{
+		"doc/movie/clip.mp4":    {Data: []byte{}},
+		"doc/movie/clip.en.vtt": {Data: []byte{}},
+		"doc/data.csv":          {Data: []byte("a,b\n1,2\n")},
+		"docx/clip.mp4":         {Data: []byte{}},
+		"docx/secret.csv":       {Data: []byte("secret\n")},
+	}
+	m2h := &Md2Html{
+		inc_cfg: &IncludeConfig{PathStack: ms_include.NewSlicePathStack("/doc/index.md")},
+	}
+
+	return &ConvertFuncParam{Md2Html: m2h, SystemFS: fsys, DocumentRoot: root}
+}
+
+func TestListMediaDocumentRoot(t *testing.T) {
+	cf := testConvertFuncParam("/doc")
+
+	names, err := cf.ListMedia("movie/clip.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, []string{"clip.en.vtt", "clip.mp4"}) {
+		t.Errorf("got %v", names)
+	}
+
+	for _, link := range []string{"../docx/clip.mp4", "/docx/clip.mp4", "https://example.com/clip.mp4"} {
+		if names, err := cf.ListMedia(link); err == nil {
+			t.Errorf("%s: got %v, want error", link, names)
+		}
+	}
+
+	if names, err := testConvertFuncParam("").ListMedia("movie/clip.mp4"); err != ErrOutsideDocumentRoot {
+		t.Errorf("empty root: got %v, %v", names, err)
+	}
+}
+
+func TestLoadTableDocumentRoot(t *testing.T) {
+	cf := testConvertFuncParam("/doc/")
+
+	if _, err := cf.LoadTable("data.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if bin, err := cf.LoadTable("../docx/secret.csv"); err != ErrOutsideDocumentRoot {
+		t.Errorf("got %q, %v", bin, err)
+	}
+}
@@ -0,0 +1,28 @@
+package md2html
+
+import (
+	md_embed "github.com/1f408/cats_eeds/md2html/embed"
+)
+
+// LinkCardCache is the offline link card metadata file, keyed by URL.
+type LinkCardCache md_embed.CardCache
+
+func (lcc *LinkCardCache) Initialize() {
+	*lcc = LinkCardCache{}
+}
+
+func (_ *LinkCardCache) MakeNew() *LinkCardCache {
+	lcc := &LinkCardCache{}
+	lcc.Initialize()
+
+	return lcc
+}
+
+func (lcc *LinkCardCache) UnmarshalTOML(decode func(interface{}) error) error {
+	if *lcc == nil {
+		lcc.Initialize()
+	}
+
+	type Raw LinkCardCache
+	return decode((*Raw)(lcc))
+}
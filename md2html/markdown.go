@@ -3,6 +3,7 @@ package md2html
 import (
 	_ "embed"
 	"io/fs"
+	"reflect"
 	"time"
 
 	"github.com/l4go/recode"
@@ -31,6 +32,32 @@ type ExtFlags struct {
 	Alerts         bool `toml:",omitempty"`
 	MsInclude      bool `toml:",omitempty"`
 	DataTable      bool `toml:",omitempty"`
+	Xref           bool `toml:",omitempty"`
+	TocMark        bool `toml:",omitempty"`
+
+	Named map[string]bool `toml:"-"`
+}
+
+func (ef *ExtFlags) UnmarshalTOML(decode func(interface{}) error) error {
+	flags := map[string]bool{}
+	if err := decode(&flags); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(ef).Elem()
+	for key, enable := range flags {
+		if i := extFlagIndex(key); i >= 0 {
+			rv.Field(i).SetBool(enable)
+			continue
+		}
+
+		if ef.Named == nil {
+			ef.Named = map[string]bool{}
+		}
+		ef.Named[key] = enable
+	}
+
+	return nil
 }
 
 type AutoIdsOptions struct {
@@ -39,6 +66,7 @@ type AutoIdsOptions struct {
 
 type FootnoteOptions struct {
 	BacklinkHTML string `toml:",omitempty"`
+	Mode         string `toml:",omitempty"`
 }
 
 type EmojiOptions struct {
@@ -46,20 +74,95 @@ type EmojiOptions struct {
 }
 
 type EmbedOptions struct {
-	Rules upath.Import[*EmbedRules] `toml:",omitempty"`
+	Rules     upath.Import[*EmbedRules]    `toml:",omitempty"`
+	CardCache upath.Import[*LinkCardCache] `toml:",omitempty"`
 }
 
 type AlertsOptions struct {
 	TitleMapping upath.Import[*AlertTitleMapping] `toml:",omitempty"`
 }
 
+type MsIncludeOptions struct {
+	MaxDepth      int      `toml:",omitempty"`
+	MaxTotalBytes int64    `toml:",omitempty"`
+	SourceExts    []string `toml:",omitempty"`
+}
+
+type MathOptions struct {
+	Renderer string            `toml:",omitempty"`
+	Macros   map[string]string `toml:",omitempty"`
+}
+
+type MermaidOptions struct {
+	Renderer string   `toml:",omitempty"`
+	Command  []string `toml:",omitempty"`
+	Timeout  int      `toml:",omitempty"`
+}
+
+type TocOptions struct {
+	MinLevel       int  `toml:",omitempty"`
+	MaxLevel       int  `toml:",omitempty"`
+	Numbered       bool `toml:",omitempty"`
+	NumberHeadings bool `toml:",omitempty"`
+	DropTitle      bool `toml:",omitempty"`
+}
+
+func (to TocOptions) fix() TocOptions {
+	if to.MinLevel < 1 || to.MinLevel > 6 {
+		to.MinLevel = 1
+	}
+	if to.MaxLevel < to.MinLevel || to.MaxLevel > 6 {
+		to.MaxLevel = 6
+	}
+	return to
+}
+
+// Override returns the options overridden by the front matter parameter.
+func (to TocOptions) Override(tp *TocParam) TocOptions {
+	if tp == nil {
+		return to
+	}
+	if tp.MinLevel != nil {
+		to.MinLevel = *tp.MinLevel
+	}
+	if tp.MaxLevel != nil {
+		to.MaxLevel = *tp.MaxLevel
+	}
+	if tp.Numbered != nil {
+		to.Numbered = *tp.Numbered
+	}
+	if tp.NumberHeadings != nil {
+		to.NumberHeadings = *tp.NumberHeadings
+	}
+	if tp.DropTitle != nil {
+		to.DropTitle = *tp.DropTitle
+	}
+	return to
+}
+
+type XrefOptions struct {
+	Figure         string `toml:",omitempty"`
+	Table          string `toml:",omitempty"`
+	Listing        string `toml:",omitempty"`
+	Section        string `toml:",omitempty"`
+	NumberSections bool   `toml:",omitempty"`
+}
+
 type MdConfig struct {
-	Extension ExtFlags
-	AutoIds   AutoIdsOptions  `toml:",omitempty"`
-	Footnote  FootnoteOptions `toml:",omitempty"`
-	Emoji     EmojiOptions    `toml:",omitempty"`
-	Embed     EmbedOptions    `toml:",omitempty"`
-	Alerts    AlertsOptions   `toml:",omitempty"`
+	Extensions []string `toml:",omitempty"`
+	Extension  ExtFlags
+	AutoIds    AutoIdsOptions   `toml:",omitempty"`
+	Footnote   FootnoteOptions  `toml:",omitempty"`
+	Emoji      EmojiOptions     `toml:",omitempty"`
+	Embed      EmbedOptions     `toml:",omitempty"`
+	Alerts     AlertsOptions    `toml:",omitempty"`
+	MsInclude  MsIncludeOptions `toml:",omitempty"`
+	Math       MathOptions      `toml:",omitempty"`
+	Mermaid    MermaidOptions   `toml:",omitempty"`
+	Xref       XrefOptions      `toml:",omitempty"`
+	Toc        TocOptions       `toml:",omitempty"`
+
+	ExtOptions map[string]*upath.Import[*ExtensionOptions] `toml:"extension_options,omitempty"`
 
 	ModTime time.Time `toml:"-"`
 	init    bool      `toml:"-"`
@@ -0,0 +1,186 @@
+package mathml
+
+import (
+	"bytes"
+	"strings"
+
+	mathjax "github.com/litao91/goldmark-mathjax"
+	"github.com/wyatt915/treeblood"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+type Config struct {
+	Macros map[string]string
+	Report diag.ReportFunc
+}
+
+type Option interface {
+	SetMathMLOption(*Config)
+}
+
+type withMacros struct {
+	value map[string]string
+}
+
+func (o *withMacros) SetMathMLOption(c *Config) {
+	c.Macros = o.value
+}
+
+func WithMacros(m map[string]string) Option {
+	return &withMacros{value: m}
+}
+
+type withReport struct {
+	value diag.ReportFunc
+}
+
+func (o *withReport) SetMathMLOption(c *Config) {
+	c.Report = o.value
+}
+
+func WithReport(f diag.ReportFunc) Option {
+	return &withReport{value: f}
+}
+
+func NewMathML(opts ...Option) goldmark.Extender {
+	return &mathmlExtension{
+		options: opts,
+	}
+}
+
+type mathmlExtension struct {
+	options []Option
+}
+
+func (e *mathmlExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(
+		util.Prioritized(mathjax.NewMathJaxBlockParser(), 701),
+	))
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(mathjax.NewInlineMathParser(), 501),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(NewMathMLRenderer(e.options...), 501),
+	))
+}
+
+type mathmlRenderer struct {
+	Config
+	pitz *treeblood.Pitziil
+}
+
+func NewMathMLRenderer(opts ...Option) renderer.NodeRenderer {
+	r := &mathmlRenderer{}
+	for _, opt := range opts {
+		opt.SetMathMLOption(&r.Config)
+	}
+
+	r.pitz = treeblood.NewPitziil(r.Macros)
+	r.pitz.PrintOneLine = true
+	return r
+}
+
+func (r *mathmlRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(mathjax.KindMathBlock, r.renderMathBlock)
+	reg.Register(mathjax.KindInlineMath, r.renderInlineMath)
+}
+
+func (r *mathmlRenderer) convert(tex []byte, display bool, source []byte, pos int) ([]byte, bool) {
+	var mml string
+	var err error
+	if display {
+		mml, err = r.pitz.DisplayStyle(string(tex))
+	} else {
+		mml, err = r.pitz.TextStyle(string(tex))
+	}
+	if err != nil {
+		if r.Report != nil {
+			line, col := diag.Position(source, pos)
+			r.Report(&diag.Diagnostic{
+				Severity: diag.Warning,
+				Line:     line,
+				Column:   col,
+				Message:  "math error: " + strings.TrimSpace(firstLine(err.Error())),
+			})
+		}
+		return nil, false
+	}
+
+	return []byte(strings.TrimSpace(mml)), true
+}
+
+func firstLine(s string) string {
+	if l, _, ok := strings.Cut(s, "<pre>"); ok {
+		return l
+	}
+	l, _, _ := strings.Cut(s, "\n")
+	return l
+}
+
+func (r *mathmlRenderer) renderMathBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var tex bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		tex.Write(seg.Value(source))
+	}
+
+	pos := -1
+	if lines.Len() > 0 {
+		pos = lines.At(0).Start
+	}
+
+	_, _ = w.WriteString(`<p><span class="math display">`)
+	if mml, ok := r.convert(tex.Bytes(), true, source, pos); ok {
+		_, _ = w.Write(mml)
+	} else {
+		_, _ = w.Write(util.EscapeHTML(tex.Bytes()))
+	}
+	_, _ = w.WriteString("</span></p>\n")
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *mathmlRenderer) renderInlineMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	pos := -1
+	if t, ok := node.FirstChild().(*ast.Text); ok {
+		pos = t.Segment.Start
+	}
+
+	var tex bytes.Buffer
+	for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+		value := c.(*ast.Text).Segment.Value(source)
+		if bytes.HasSuffix(value, []byte("\n")) {
+			tex.Write(value[:len(value)-1])
+			if c != node.LastChild() {
+				tex.WriteByte(' ')
+			}
+		} else {
+			tex.Write(value)
+		}
+	}
+
+	_, _ = w.WriteString(`<span class="math inline">`)
+	if mml, ok := r.convert(tex.Bytes(), false, source, pos); ok {
+		_, _ = w.Write(mml)
+	} else {
+		_, _ = w.Write(util.EscapeHTML(tex.Bytes()))
+	}
+	_, _ = w.WriteString("</span>")
+
+	return ast.WalkSkipChildren, nil
+}
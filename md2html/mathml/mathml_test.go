@@ -0,0 +1,47 @@
+package mathml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+func TestMathML(t *testing.T) {
+	diags := []*diag.Diagnostic{}
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewMathML(
+				WithMacros(map[string]string{"RR": `\mathbb{R}`}),
+				WithReport(func(d *diag.Diagnostic) { diags = append(diags, d) }),
+			),
+		),
+	)
+
+	src := []byte("a $x \\in \\RR$ b\n\n$$\n\\frac{1}{2}\n$$\n\nc $\\frac{a$ d\n")
+	var buf bytes.Buffer
+	if err := markdown.Convert(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		`<span class="math inline"><math `,
+		`<mi>ℝ</mi>`,
+		`<p><span class="math display"><math `,
+		`display="block"`,
+		`<mfrac><mn>1</mn><mn>2</mn></mfrac>`,
+		`<span class="math inline">\frac{a</span>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	if len(diags) != 1 || diags[0].Line != 7 || diags[0].Column != 4 {
+		t.Errorf("got diagnostics %v", diags)
+	}
+}
@@ -8,8 +8,14 @@ import (
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 
+	"github.com/1f408/cats_eeds/md2html/diag"
+	"github.com/1f408/cats_eeds/md2html/dt_table"
+	md_embed "github.com/1f408/cats_eeds/md2html/embed"
+	"github.com/1f408/cats_eeds/md2html/mermaid"
 	"github.com/1f408/cats_eeds/md2html/ms_include"
+	"github.com/1f408/cats_eeds/md2html/tasklist"
 	"github.com/1f408/cats_eeds/md2html/uniqid"
 )
 
@@ -20,6 +26,15 @@ type Md2Html struct {
 	md_parser goldmark.Markdown
 	id_tbl    uniqid.IdsTable
 	inc_cfg   *IncludeConfig
+	inc_graph *IncludeGraph
+	diags     *diag.List
+	svgs      *mermaid.Store
+	mmd_rndr  mermaid.Renderer
+
+	task_index bool
+	tasks      TaskCount
+	toc_param  *TocParam
+	toc_token  string
 }
 
 type Md2HtmlConfig struct {
@@ -28,14 +43,52 @@ type Md2HtmlConfig struct {
 	SystemFS    fs.FS
 	FrontMatter FrontMatterConfig
 	StartMdFile string
+
+	DocumentRoot string
+	IncludeGraph *IncludeGraph
+	CardFetcher  LinkCardFetcher
+	TaskIndex    bool
+	TocParam     *TocParam
+	LineOffset   int
 }
 
 type IncludeConvertHtml = ms_include.ConvertHtmlFunc
 type IncludePathStack = ms_include.PathStack
 
+type IncludePartConvertHtml = ms_include.PartConvertHtmlFunc
+type IncludeErrorHandler = ms_include.ErrorHandlerFunc
+
+type IncludeWarning = ms_include.IncludeError
+
+type TableLoader = dt_table.TableLoaderFunc
+type MediaLister = md_embed.MediaListFunc
+type LinkCardFetcher = md_embed.CardFetcher
+type TaskCount = tasklist.TaskCount
+
 type IncludeConfig struct {
-	ConvertHtml IncludeConvertHtml
-	PathStack   IncludePathStack
+	ConvertHtml     IncludeConvertHtml
+	PartConvertHtml IncludePartConvertHtml
+	PathStack       IncludePathStack
+	ErrorHandler    IncludeErrorHandler
+	LoadTable       TableLoader
+	ListMedia       MediaLister
+	CardFetcher     LinkCardFetcher
+
+	warnings []*IncludeWarning
+}
+
+type ConvertResult struct {
+	Html        []byte
+	Toc         []byte
+	Title       []byte
+	TableList   []byte
+	Tasks       TaskCount
+	Diagnostics []*diag.Diagnostic
+}
+
+type includeLimiter interface {
+	SetLimits(max_depth int, max_bytes int64)
+	AddBytes(n int) error
 }
 
 func NewMd2Html(cfg *Md2HtmlConfig) *Md2Html {
@@ -56,25 +109,46 @@ func NewMd2Html(cfg *Md2HtmlConfig) *Md2Html {
 	}
 
 	m2h := &Md2Html{
-		cfg:     md_cfg,
-		sani:    newSanitizer(),
-		sys_ids: cfg.SystemIds,
-		id_tbl:  id_tbl,
+		cfg:       md_cfg,
+		sani:      newSanitizer(),
+		sys_ids:   cfg.SystemIds,
+		id_tbl:    id_tbl,
+		inc_graph: cfg.IncludeGraph,
+		diags:     diag.NewList(),
+		svgs:      mermaid.NewStore(),
+		toc_token: uniqid.NewToken("toc-"),
+
+		task_index: cfg.TaskIndex,
+		toc_param:  cfg.TocParam,
 	}
+	m2h.diags.SetLineOffset(cfg.StartMdFile, cfg.LineOffset)
 
 	cf_pm := &ConvertFuncParam{
-		Md2Html:     m2h,
-		SystemFS:    cfg.SystemFS,
-		FrontMatter: cfg.FrontMatter,
+		Md2Html:      m2h,
+		SystemFS:     cfg.SystemFS,
+		FrontMatter:  cfg.FrontMatter,
+		DocumentRoot: cfg.DocumentRoot,
 	}
 
 	inc_cfg := &IncludeConfig{
-		ConvertHtml: cf_pm.ConvertHtml,
-		PathStack:   ms_include.NewSlicePathStack(cfg.StartMdFile),
+		ConvertHtml:     cf_pm.ConvertHtml,
+		PartConvertHtml: cf_pm.PartConvertHtml,
+		LoadTable:       cf_pm.LoadTable,
+		ListMedia:       cf_pm.ListMedia,
+		CardFetcher:     cfg.CardFetcher,
+		PathStack:       ms_include.NewSlicePathStack(cfg.StartMdFile),
+	}
+	inc_cfg.ErrorHandler = func(w *IncludeWarning) {
+		inc_cfg.warnings = append(inc_cfg.warnings, w)
+		m2h.reportInclude(w)
+	}
+	if lim, ok := inc_cfg.PathStack.(includeLimiter); ok {
+		lim.SetLimits(md_cfg.MsInclude.MaxDepth, md_cfg.MsInclude.MaxTotalBytes)
 	}
 	m2h.inc_cfg = inc_cfg
+	m2h.mmd_rndr = md_cfg.Mermaid.NewRenderer(m2h.Report)
 
-	parser_exts := NewParserExts(md_cfg, id_tbl, inc_cfg)
+	parser_exts := NewParserExts(md_cfg, id_tbl, inc_cfg, m2h.Report, m2h.mmd_rndr, m2h.svgs, m2h.toc_token)
 	md_parser := goldmark.New(
 		goldmark.WithExtensions(parser_exts...),
 		goldmark.WithParserOptions(
@@ -100,7 +174,9 @@ func (m2h *Md2Html) ResetIds() {
 }
 
 func (m2h *Md2Html) NewLocalSpec(md_cfg *MdConfig) *Md2Html {
-	parser_exts := NewParserExts(md_cfg, m2h.id_tbl, m2h.inc_cfg)
+	// the mermaid renderer runs external commands, so it is taken from the
+	// server config only, never from a local markdown config.
+	parser_exts := NewParserExts(md_cfg, m2h.id_tbl, m2h.inc_cfg, m2h.Report, m2h.mmd_rndr, m2h.svgs, m2h.toc_token)
 
 	md_parser := goldmark.New(
 		goldmark.WithExtensions(parser_exts...),
@@ -121,26 +197,165 @@ func (m2h *Md2Html) NewLocalSpec(md_cfg *MdConfig) *Md2Html {
 		sys_ids:   m2h.sys_ids,
 		id_tbl:    m2h.id_tbl,
 		inc_cfg:   m2h.inc_cfg,
+		inc_graph: m2h.inc_graph,
+		diags:     m2h.diags,
+		svgs:      m2h.svgs,
+		mmd_rndr:  m2h.mmd_rndr,
+		toc_token: m2h.toc_token,
+
+		task_index: m2h.task_index,
+		toc_param:  m2h.toc_param,
 	}
 }
 
-func (m2h *Md2Html) md2html(md []byte) []byte {
-	var buf bytes.Buffer
-	opts := []parser.ParseOption{}
+type includedLister interface {
+	Included() []string
+}
 
+func (m2h *Md2Html) IncludedFiles() []string {
+	if il, ok := m2h.inc_cfg.PathStack.(includedLister); ok {
+		return il.Included()
+	}
+	return []string{}
+}
+
+type includeEdgeLister interface {
+	Start() string
+	Edges() []IncludeEdge
+	Traversed() []string
+}
+
+func (m2h *Md2Html) IncludeEdges() []IncludeEdge {
+	if el, ok := m2h.inc_cfg.PathStack.(includeEdgeLister); ok {
+		return el.Edges()
+	}
+	return []IncludeEdge{}
+}
+
+func (m2h *Md2Html) IncludeWarnings() []*IncludeWarning {
+	return append([]*IncludeWarning{}, m2h.inc_cfg.warnings...)
+}
+
+type includeChainLister interface {
+	Chain() []string
+}
+
+func (m2h *Md2Html) currentFile() string {
+	if cl, ok := m2h.inc_cfg.PathStack.(includeChainLister); ok {
+		if chain := cl.Chain(); len(chain) > 0 {
+			return chain[len(chain)-1]
+		}
+	}
+	return ""
+}
+
+func (m2h *Md2Html) Report(d *diag.Diagnostic) {
+	if d.File == "" {
+		d.File = m2h.currentFile()
+	}
+	m2h.diags.Report(d)
+}
+
+func (m2h *Md2Html) reportInclude(w *IncludeWarning) {
+	file := ""
+	if len(w.Chain) >= 2 {
+		file = w.Chain[len(w.Chain)-2]
+	}
+
+	m2h.Report(&diag.Diagnostic{
+		Severity: diag.Error,
+		File:     file,
+		Line:     w.Line,
+		Column:   w.Column,
+		Message:  w.Error(),
+	})
+}
+
+func (m2h *Md2Html) Diagnostics() []*diag.Diagnostic {
+	return m2h.diags.Items()
+}
+
+func (m2h *Md2Html) updateIncludeGraph() {
+	if m2h.inc_graph == nil {
+		return
+	}
+	if el, ok := m2h.inc_cfg.PathStack.(includeEdgeLister); ok {
+		m2h.inc_graph.Update(el.Start(), el.Edges(), el.Traversed())
+	}
+}
+
+type includeDepther interface {
+	Depth() int
+}
+
+func (m2h *Md2Html) isStartFile() bool {
+	if dp, ok := m2h.inc_cfg.PathStack.(includeDepther); ok {
+		return dp.Depth() == 0
+	}
+	return true
+}
+
+func (m2h *Md2Html) newContext() parser.Context {
 	new_ids, err := NewAutoIds(m2h.md_parser, m2h.cfg.AutoIds.Type, m2h.id_tbl)
 	if err != nil {
 		panic(fmt.Errorf("Md2Html config error: %s", err))
 	}
 
 	ctx := parser.NewContext(parser.WithIDs(new_ids))
+	if m2h.task_index && m2h.isStartFile() {
+		tasklist.EnableTaskIndex(ctx)
+	}
+	return ctx
+}
+
+func (m2h *Md2Html) md2html(md []byte) []byte {
+	var buf bytes.Buffer
+	opts := []parser.ParseOption{}
+
+	ctx := m2h.newContext()
 	opts = append(opts, parser.WithContext(ctx))
 
+	is_start := m2h.isStartFile()
 	m2h.md_parser.Convert(md, &buf, opts...)
+	if is_start {
+		m2h.tasks = tasklist.Count(ctx)
+	}
 
 	return buf.Bytes()
 }
 
+func (m2h *Md2Html) parseTasks(md []byte) parser.Context {
+	ctx := parser.NewContext()
+	tasklist.EnableTaskIndex(ctx)
+	m2h.md_parser.Parser().Parse(text.NewReader(md), parser.WithContext(ctx))
+
+	return ctx
+}
+
+// TaskMarks returns the source offsets of the task list marks in md,
+// in the order of the data-task-index attributes.
+func (m2h *Md2Html) TaskMarks(md []byte) []int {
+	return tasklist.TaskMarks(m2h.parseTasks(md))
+}
+
+var taskCountParser = goldmark.New(goldmark.WithExtensions(tasklist.TaskList)).Parser()
+
+// CountTasks counts the task items of md without rendering it.
+// It parses md with the CommonMark blocks and the task list only, so no
+// other extension runs. The tasks of the included files are not counted.
+func CountTasks(md []byte) TaskCount {
+	ctx := parser.NewContext()
+	taskCountParser.Parse(text.NewReader(md), parser.WithContext(ctx))
+
+	return tasklist.Count(ctx)
+}
+
+// TaskCount returns the number of the task items of the last converted
+// document.
+func (m2h *Md2Html) TaskCount() TaskCount {
+	return m2h.tasks
+}
+
 func (m2h *Md2Html) sanitize(html []byte) ([]byte, error) {
 	if m2h.sani == nil {
 		return html, nil
@@ -149,19 +364,43 @@ func (m2h *Md2Html) sanitize(html []byte) ([]byte, error) {
 }
 
 func (m2h *Md2Html) Convert(md []byte) ([]byte, []byte, []byte, error) {
-	html_bin, err := m2h.sanitize(m2h.md2html(md))
+	res, err := m2h.ConvertWithDiagnostics(md)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	return res.Html, res.Toc, res.Title, nil
+}
+
+func (m2h *Md2Html) ConvertWithDiagnostics(md []byte) (*ConvertResult, error) {
+	raw_html := m2h.md2html(md)
+	html_bin, err := m2h.sanitize(raw_html)
+	m2h.updateIncludeGraph()
+	if err != nil {
+		return nil, err
+	}
+	m2h.reportStripped(raw_html, html_bin)
+	html_bin = m2h.svgs.Replace(html_bin)
 
-	var toc_bin []byte = nil
-	var title_bin []byte = nil
+	res := &ConvertResult{Html: html_bin, Tasks: m2h.tasks}
 	if toc, terr := NewToc(html_bin); terr == nil {
-		title_bin = []byte(toc.Title)
-		toc_bin, err = m2h.sanitize(toc.ConvertHtml())
+		res.Title = []byte(toc.Title)
+
+		toc_opts := m2h.cfg.Toc.Override(m2h.toc_param)
+		toc.Filter(toc_opts)
+		if toc_opts.Numbered && toc_opts.NumberHeadings {
+			res.Html = toc.NumberHeadings(res.Html)
+		}
+		res.Toc, err = m2h.sanitize(toc.ConvertHtml())
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, err
+		}
+		res.TableList, err = m2h.sanitize(toc.ConvertTablesHtml())
+		if err != nil {
+			return nil, err
 		}
 	}
-	return html_bin, toc_bin, title_bin, nil
+	res.Html = bytes.ReplaceAll(res.Html, []byte(m2h.toc_token), res.Toc)
+	res.Diagnostics = m2h.Diagnostics()
+
+	return res, nil
 }
@@ -12,22 +12,32 @@ import (
 	"github.com/yuin/goldmark/util"
 
 	"github.com/1f408/cats_eeds/md2html/alerts"
+	"github.com/1f408/cats_eeds/md2html/diag"
 	"github.com/1f408/cats_eeds/md2html/dt_table"
 	md_embed "github.com/1f408/cats_eeds/md2html/embed"
 	"github.com/1f408/cats_eeds/md2html/footnote"
+	"github.com/1f408/cats_eeds/md2html/geomap"
+	"github.com/1f408/cats_eeds/md2html/mathml"
+	"github.com/1f408/cats_eeds/md2html/mermaid"
 	"github.com/1f408/cats_eeds/md2html/ms_include"
 	"github.com/1f408/cats_eeds/md2html/tasklist"
+	"github.com/1f408/cats_eeds/md2html/tocmark"
 	"github.com/1f408/cats_eeds/md2html/uniqid"
+	"github.com/1f408/cats_eeds/md2html/xref"
 )
 
-func NewParserExts(mc *MdConfig, id_tbl uniqid.IdsTable, inc_cfg *IncludeConfig) []goldmark.Extender {
+func NewParserExts(mc *MdConfig, id_tbl uniqid.IdsTable, inc_cfg *IncludeConfig, report diag.ReportFunc, mmd_rndr mermaid.Renderer, svgs *mermaid.Store, toc_token string) []goldmark.Extender {
 	if mc == nil {
 		mc = NewMdConfigDefault()
 	}
 
 	parser_exts := []goldmark.Extender{}
 	if mc.Extension.DataTable {
-		parser_exts = append(parser_exts, dt_table.Table)
+		tbl_opts := []dt_table.CsvTableOption{dt_table.WithTableReport(report)}
+		if inc_cfg.LoadTable != nil {
+			tbl_opts = append(tbl_opts, dt_table.WithTableLoader(inc_cfg.LoadTable))
+		}
+		parser_exts = append(parser_exts, dt_table.Table, dt_table.NewCsvTable(tbl_opts...))
 	} else if mc.Extension.Table {
 		parser_exts = append(parser_exts, extension.Table)
 	}
@@ -47,6 +57,14 @@ func NewParserExts(mc *MdConfig, id_tbl uniqid.IdsTable, inc_cfg *IncludeConfig)
 				footnote.WithFootnoteBacklinkHTML(
 					[]byte(mc.Footnote.BacklinkHTML)))
 		}
+		if mode := footnote.FootnoteMode(mc.Footnote.Mode); mode.IsValid() {
+			fn_ext = append(fn_ext, footnote.WithFootnoteMode(mode))
+		} else if mode != "" && report != nil {
+			report(&diag.Diagnostic{
+				Severity: diag.Warning,
+				Message:  "unknown footnote mode: " + mc.Footnote.Mode,
+			})
+		}
 
 		parser_exts = append(parser_exts, footnote.NewFootnote(id_tbl, fn_ext...))
 	}
@@ -81,9 +99,22 @@ func NewParserExts(mc *MdConfig, id_tbl uniqid.IdsTable, inc_cfg *IncludeConfig)
 			))
 	}
 	if mc.Extension.Math {
-		parser_exts = append(parser_exts, mathjax.NewMathJax(
-			mathjax.WithInlineDelim("", ""),
-			mathjax.WithBlockDelim("", "")))
+		switch mc.Math.Renderer {
+		case "mathml":
+			parser_exts = append(parser_exts, mathml.NewMathML(
+				mathml.WithMacros(mc.Math.Macros),
+				mathml.WithReport(report)))
+		default:
+			if mc.Math.Renderer != "" && mc.Math.Renderer != "passthrough" && report != nil {
+				report(&diag.Diagnostic{
+					Severity: diag.Warning,
+					Message:  "unknown math renderer: " + mc.Math.Renderer,
+				})
+			}
+			parser_exts = append(parser_exts, mathjax.NewMathJax(
+				mathjax.WithInlineDelim("", ""),
+				mathjax.WithBlockDelim("", "")))
+		}
 	}
 	if mc.Extension.Embed {
 		vd_opts := []md_embed.VideoOptions{}
@@ -119,27 +150,101 @@ func NewParserExts(mc *MdConfig, id_tbl uniqid.IdsTable, inc_cfg *IncludeConfig)
 					Player:         p.Player,
 					Allow:          p.Allow,
 					Referrerpolicy: p.Referrerpolicy,
+					Sandbox:        p.Sandbox,
+					Loading:        p.Loading,
+					Width:          p.Width,
+					Height:         p.Height,
+					Consent:        p.Consent,
+					ConsentText:    p.ConsentText,
+				})
+		}
+
+		card_opts := []md_embed.CardOptions{}
+		for _, p := range mc.Embed.Rules.Value.Card {
+			card_opts = append(card_opts,
+				md_embed.CardOptions{
+					SiteId: p.SiteId,
+					Host:   p.Host,
+					Type:   p.Type,
+					Path:   p.Path,
+					Query:  p.Query,
+					Regex:  p.Regex,
 				})
 		}
 
-		parser_exts = append(parser_exts, md_embed.NewEmbed(
+		em_opts := []md_embed.EmbedOption{
 			md_embed.WithEmbedVideoExt(mc.Embed.Rules.Value.VideoExt),
 			md_embed.WithEmbedAudioExt(mc.Embed.Rules.Value.AudioExt),
 			md_embed.WithEmbedVideoUrl(vd_opts),
 			md_embed.WithEmbedAudioUrl(ad_opts),
 			md_embed.WithEmbedIframeUrl(ifm_opts),
+			md_embed.WithEmbedCardUrl(card_opts),
+			md_embed.WithEmbedReport(report),
+		}
+		if inc_cfg.CardFetcher != nil {
+			em_opts = append(em_opts, md_embed.WithEmbedCardFetcher(inc_cfg.CardFetcher))
+		} else if mc.Embed.CardCache.Value != nil {
+			em_opts = append(em_opts, md_embed.WithEmbedCardFetcher(
+				md_embed.CardCache(*mc.Embed.CardCache.Value)))
+		}
+		if inc_cfg.ListMedia != nil {
+			em_opts = append(em_opts, md_embed.WithEmbedMediaList(inc_cfg.ListMedia))
+		}
+
+		parser_exts = append(parser_exts, md_embed.NewEmbed(em_opts...))
+	}
+
+	if mc.Extension.Mermaid {
+		if mmd_rndr == nil {
+			mmd_rndr = mermaid.PassThrough{}
+		}
+		parser_exts = append(parser_exts, mermaid.NewMermaid(
+			mermaid.WithRenderer(mmd_rndr),
+			mermaid.WithStore(svgs),
+			mermaid.WithReport(report),
+		))
+	}
+
+	if mc.Extension.GeoMap {
+		parser_exts = append(parser_exts, geomap.NewGeoMap(
+			geomap.WithReport(report),
 		))
 	}
 
 	if mc.Extension.Alerts {
 		parser_exts = append(parser_exts, alerts.NewAlertBlock(
-			alerts.WithTitleHtmlMaping(alerts.TitleHtmlMapping(*mc.Alerts.TitleMapping.Value)),
+			alerts.WithAlertTypes(mc.Alerts.TitleMapping.Value.AlertTypes()),
+			alerts.WithReport(report),
 		))
 	}
+	if mc.Extension.Xref {
+		parser_exts = append(parser_exts, xref.NewXref(
+			xref.WithNames(xref.Names{
+				Figure:  mc.Xref.Figure,
+				Table:   mc.Xref.Table,
+				Listing: mc.Xref.Listing,
+				Section: mc.Xref.Section,
+			}),
+			xref.WithNumberSections(mc.Xref.NumberSections),
+			xref.WithReport(report),
+		))
+	}
+	if mc.Extension.TocMark {
+		parser_exts = append(parser_exts, tocmark.NewTocMark(tocmark.WithToken(toc_token)))
+	}
 	if mc.Extension.MsInclude {
+		inc_opts := []ms_include.Option{}
+		if inc_cfg.PartConvertHtml != nil {
+			inc_opts = append(inc_opts, ms_include.WithPartConvertHtml(inc_cfg.PartConvertHtml))
+		}
+		if inc_cfg.ErrorHandler != nil {
+			inc_opts = append(inc_opts, ms_include.WithErrorHandler(inc_cfg.ErrorHandler))
+		}
 		parser_exts = append(parser_exts, ms_include.NewMsInclude(
-			inc_cfg.ConvertHtml, inc_cfg.PathStack))
+			inc_cfg.ConvertHtml, inc_cfg.PathStack, inc_opts...))
 	}
 
+	parser_exts = append(parser_exts, registeredParserExts(mc, id_tbl, inc_cfg, report)...)
+
 	return parser_exts
 }
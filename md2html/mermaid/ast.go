@@ -0,0 +1,29 @@
+package mermaid
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+type MermaidBlockNode struct {
+	ast.BaseBlock
+	Token string
+}
+
+func (n *MermaidBlockNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Token": n.Token}, nil)
+}
+
+var KindMermaidBlock = ast.NewNodeKind("MermaidBlock")
+
+func (n *MermaidBlockNode) Kind() ast.NodeKind {
+	return KindMermaidBlock
+}
+
+func NewMermaidBlockNode(token string) *MermaidBlockNode {
+	n := &MermaidBlockNode{
+		BaseBlock: ast.BaseBlock{},
+		Token:     token,
+	}
+	n.SetAttributeString("class", "markdown-mermaid")
+	return n
+}
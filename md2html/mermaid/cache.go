@@ -0,0 +1,91 @@
+package mermaid
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+type cacheEntry struct {
+	key [sha256.Size]byte
+	svg []byte
+}
+
+type Cache struct {
+	mtx   sync.Mutex
+	max   int
+	lru   *list.List
+	items map[[sha256.Size]byte]*list.Element
+}
+
+func NewCache(max int) *Cache {
+	return &Cache{
+		max:   max,
+		lru:   list.New(),
+		items: map[[sha256.Size]byte]*list.Element{},
+	}
+}
+
+func (c *Cache) Get(key [sha256.Size]byte) ([]byte, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*cacheEntry).svg, true
+}
+
+func (c *Cache) Put(key [sha256.Size]byte, svg []byte) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry).svg = svg
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.lru.PushFront(&cacheEntry{key: key, svg: svg})
+	for c.max > 0 && c.lru.Len() > c.max {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.items, el.Value.(*cacheEntry).key)
+	}
+}
+
+type cachedRenderer struct {
+	r     Renderer
+	cache *Cache
+	scope string
+}
+
+// NewCachedRenderer caches successful results of r by content hash.
+// scope separates entries of renderers with different settings.
+func NewCachedRenderer(r Renderer, c *Cache, scope string) Renderer {
+	return &cachedRenderer{r: r, cache: c, scope: scope}
+}
+
+func (cr *cachedRenderer) RenderSVG(src []byte) ([]byte, error) {
+	h := sha256.New()
+	h.Write([]byte(cr.scope))
+	h.Write([]byte{0})
+	h.Write(src)
+
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+
+	if svg, ok := cr.cache.Get(key); ok {
+		return svg, nil
+	}
+
+	svg, err := cr.r.RenderSVG(src)
+	if err != nil {
+		return nil, err
+	}
+	cr.cache.Put(key, svg)
+
+	return svg, nil
+}
@@ -0,0 +1,75 @@
+package mermaid
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+type Config struct {
+	Renderer Renderer
+	Store    *Store
+	Report   diag.ReportFunc
+}
+
+type Option interface {
+	SetMermaidOption(*Config)
+}
+
+type withRenderer struct {
+	value Renderer
+}
+
+func (o *withRenderer) SetMermaidOption(c *Config) {
+	c.Renderer = o.value
+}
+
+func WithRenderer(r Renderer) Option {
+	return &withRenderer{value: r}
+}
+
+type withStore struct {
+	value *Store
+}
+
+func (o *withStore) SetMermaidOption(c *Config) {
+	c.Store = o.value
+}
+
+func WithStore(s *Store) Option {
+	return &withStore{value: s}
+}
+
+type withReport struct {
+	value diag.ReportFunc
+}
+
+func (o *withReport) SetMermaidOption(c *Config) {
+	c.Report = o.value
+}
+
+func WithReport(f diag.ReportFunc) Option {
+	return &withReport{value: f}
+}
+
+func NewMermaid(opts ...Option) goldmark.Extender {
+	return &mermaidExtension{
+		options: opts,
+	}
+}
+
+type mermaidExtension struct {
+	options []Option
+}
+
+func (e *mermaidExtension) Extend(md goldmark.Markdown) {
+	md.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(NewMermaidTransformer(e.options...), 500),
+	))
+	md.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(NewMermaidRenderer(), 500),
+	))
+}
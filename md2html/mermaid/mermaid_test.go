@@ -0,0 +1,75 @@
+package mermaid
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+var errDummy = errors.New("dummy error")
+
+func TestMermaid(t *testing.T) {
+	calls := 0
+	r := RendererFunc(func(src []byte) ([]byte, error) {
+		calls++
+		if bytes.Contains(src, []byte("FAIL")) {
+			return nil, errDummy
+		}
+		return []byte("<svg>" + strings.TrimSpace(string(src)) + "</svg>"), nil
+	})
+
+	store := NewStore()
+	diags := []*diag.Diagnostic{}
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewMermaid(
+				WithRenderer(NewCachedRenderer(r, NewCache(8), "test")),
+				WithStore(store),
+				WithReport(func(d *diag.Diagnostic) { diags = append(diags, d) }),
+			),
+		),
+	)
+
+	src := []byte("```mermaid\ngraph TD\n```\n\n```mermaid\ngraph TD\n```\n\n```mermaid\nFAIL\n```\n\n```go\nx\n```\n")
+	var buf bytes.Buffer
+	if err := markdown.Convert(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got := string(store.Replace(buf.Bytes()))
+	want := `<div class="markdown-mermaid"><svg>graph TD</svg></div>
+<div class="markdown-mermaid"><svg>graph TD</svg></div>
+<pre><code class="language-mermaid">FAIL
+</code></pre>
+<pre><code class="language-go">x
+</code></pre>
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if calls != 2 {
+		t.Errorf("got %d renderer calls, want 2", calls)
+	}
+	if len(diags) != 1 || diags[0].Line != 9 {
+		t.Errorf("got diagnostics %v", diags)
+	}
+}
+
+func TestPassThrough(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithExtensions(NewMermaid()),
+	)
+
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte("```mermaid\ngraph TD\n```\n"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "<pre><code class=\"language-mermaid\">graph TD\n</code></pre>\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
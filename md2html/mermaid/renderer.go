@@ -0,0 +1,82 @@
+package mermaid
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var ErrPassThrough = errors.New("mermaid pass-through")
+
+// Renderer converts mermaid source into an SVG document.
+// Returning ErrPassThrough leaves the fenced code block untouched.
+type Renderer interface {
+	RenderSVG(src []byte) ([]byte, error)
+}
+
+type RendererFunc func(src []byte) ([]byte, error)
+
+func (f RendererFunc) RenderSVG(src []byte) ([]byte, error) {
+	return f(src)
+}
+
+type PassThrough struct{}
+
+func (_ PassThrough) RenderSVG(src []byte) ([]byte, error) {
+	return nil, ErrPassThrough
+}
+
+var DefaultCommand = []string{
+	"mmdc", "--input", "-", "--output", "-", "--outputFormat", "svg", "--quiet",
+}
+
+const DefaultTimeout = 10 * time.Second
+
+type CommandRenderer struct {
+	Command []string
+	Timeout time.Duration
+}
+
+func NewCommandRenderer(cmd []string, timeout time.Duration) *CommandRenderer {
+	if len(cmd) == 0 {
+		cmd = DefaultCommand
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &CommandRenderer{Command: cmd, Timeout: timeout}
+}
+
+func (cr *CommandRenderer) RenderSVG(src []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cr.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cr.Command[0], cr.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(src)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return nil, fmt.Errorf("mermaid command error: %w", err)
+		}
+		return nil, fmt.Errorf("mermaid command error: %w: %s", err, msg)
+	}
+
+	svg := stdout.Bytes()
+	if i := bytes.Index(svg, []byte("<svg")); i > 0 {
+		svg = svg[i:]
+	}
+	if !bytes.HasPrefix(svg, []byte("<svg")) {
+		return nil, errors.New("mermaid command error: no svg output")
+	}
+
+	return svg, nil
+}
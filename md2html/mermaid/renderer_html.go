@@ -0,0 +1,35 @@
+package mermaid
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+var MermaidBlockAttributeFilter = html.GlobalAttributeFilter
+
+type mermaidRenderer struct{}
+
+func NewMermaidRenderer() renderer.NodeRenderer {
+	return &mermaidRenderer{}
+}
+
+func (r *mermaidRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMermaidBlock, r.renderMermaidBlock)
+}
+
+func (r *mermaidRenderer) renderMermaidBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*MermaidBlockNode)
+	_, _ = w.WriteString("<div")
+	html.RenderAttributes(w, n, MermaidBlockAttributeFilter)
+	_ = w.WriteByte('>')
+	_, _ = w.WriteString(n.Token)
+	_, _ = w.WriteString("</div>\n")
+
+	return ast.WalkSkipChildren, nil
+}
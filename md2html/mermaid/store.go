@@ -0,0 +1,45 @@
+package mermaid
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/1f408/cats_eeds/md2html/uniqid"
+)
+
+// Store keeps rendered SVGs out of the HTML until it has been sanitized.
+type Store struct {
+	mtx    sync.Mutex
+	prefix string
+	svgs   map[string][]byte
+}
+
+func NewStore() *Store {
+	return &Store{
+		prefix: uniqid.NewToken("mermaid-"),
+		svgs:   map[string][]byte{},
+	}
+}
+
+func (s *Store) Put(svg []byte) string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	token := fmt.Sprintf("%s-%06d", s.prefix, len(s.svgs))
+	s.svgs[token] = svg
+	return token
+}
+
+func (s *Store) Replace(html []byte) []byte {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(s.svgs) == 0 || !bytes.Contains(html, []byte(s.prefix)) {
+		return html
+	}
+	for token, svg := range s.svgs {
+		html = bytes.ReplaceAll(html, []byte(token), svg)
+	}
+	return html
+}
@@ -0,0 +1,80 @@
+package mermaid
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+type mermaidTransformer struct {
+	Config
+}
+
+func NewMermaidTransformer(opts ...Option) parser.ASTTransformer {
+	t := &mermaidTransformer{
+		Config: Config{Renderer: PassThrough{}},
+	}
+	for _, opt := range opts {
+		opt.SetMermaidOption(&t.Config)
+	}
+	if t.Store == nil {
+		t.Store = NewStore()
+	}
+
+	return t
+}
+
+func (t *mermaidTransformer) report(src []byte, n ast.Node, err error) {
+	if t.Report == nil {
+		return
+	}
+
+	d := &diag.Diagnostic{Severity: diag.Warning, Message: err.Error()}
+	if n.Lines().Len() > 0 {
+		d.Line, _ = diag.Position(src, n.Lines().At(0).Start)
+		d.Line--
+		d.Column = 1
+	}
+	t.Report(d)
+}
+
+func (t *mermaidTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	src := reader.Source()
+
+	blocks := []*ast.FencedCodeBlock{}
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if fc, ok := n.(*ast.FencedCodeBlock); ok &&
+			bytes.Equal(fc.Language(src), []byte("mermaid")) {
+			blocks = append(blocks, fc)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, fc := range blocks {
+		var code bytes.Buffer
+		lines := fc.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			code.Write(seg.Value(src))
+		}
+
+		svg, err := t.Renderer.RenderSVG(code.Bytes())
+		if err != nil {
+			if !errors.Is(err, ErrPassThrough) {
+				t.report(src, fc, err)
+			}
+			continue
+		}
+
+		mn := NewMermaidBlockNode(t.Store.Put(svg))
+		fc.Parent().ReplaceChild(fc.Parent(), fc, mn)
+	}
+}
@@ -0,0 +1,68 @@
+package md2html
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+	"github.com/1f408/cats_eeds/md2html/mermaid"
+)
+
+var mermaidCache = mermaid.NewCache(256)
+
+var mermaidRenderers = struct {
+	mtx sync.RWMutex
+	tbl map[string]mermaid.Renderer
+}{tbl: map[string]mermaid.Renderer{}}
+
+// RegisterMermaidRenderer makes r selectable by name from the [mermaid] renderer setting.
+func RegisterMermaidRenderer(name string, r mermaid.Renderer) {
+	mermaidRenderers.mtx.Lock()
+	defer mermaidRenderers.mtx.Unlock()
+
+	mermaidRenderers.tbl[name] = r
+}
+
+func (mo *MermaidOptions) newRenderer(report diag.ReportFunc) (mermaid.Renderer, string) {
+	switch mo.Renderer {
+	case "", "passthrough":
+		return mermaid.PassThrough{}, ""
+	case "command":
+		cr := mermaid.NewCommandRenderer(mo.Command, time.Duration(mo.Timeout)*time.Second)
+		return cr, "command\x00" + strings.Join(cr.Command, "\x00")
+	}
+
+	mermaidRenderers.mtx.RLock()
+	r, ok := mermaidRenderers.tbl[mo.Renderer]
+	mermaidRenderers.mtx.RUnlock()
+	if !ok {
+		if report != nil {
+			report(&diag.Diagnostic{
+				Severity: diag.Warning,
+				Message:  "unknown mermaid renderer: " + mo.Renderer,
+			})
+		}
+		return mermaid.PassThrough{}, ""
+	}
+
+	return r, "named\x00" + mo.Renderer
+}
+
+func (mo *MermaidOptions) NewRenderer(report diag.ReportFunc) mermaid.Renderer {
+	r, scope := mo.newRenderer(report)
+	if scope == "" {
+		return r
+	}
+
+	sani := newSvgSanitizer()
+	sanitized := mermaid.RendererFunc(func(src []byte) ([]byte, error) {
+		svg, err := r.RenderSVG(src)
+		if err != nil {
+			return nil, err
+		}
+		return sani.Sanitize(svg)
+	})
+
+	return mermaid.NewCachedRenderer(sanitized, mermaidCache, scope)
+}
@@ -0,0 +1,49 @@
+package md2html
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLocalSpecMermaidRenderer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"md.conf": {Data: []byte("[extension]\nmermaid = true\nms_include = true\n")},
+		"doc/local.conf": {Data: []byte("[extension]\nmermaid = true\nms_include = true\n" +
+			"[mermaid]\nrenderer = \"command\"\ncommand = [\"/nonexistent/mermaid-cmd\"]\n")},
+		"doc/part.md":    {Data: []byte("---\nproduct: cats\nmarkdown_config: /etc/local.conf\n---\n```mermaid\ngraph TD\n```\n")},
+		"etc/local.conf": {Data: []byte("[extension]\nmermaid = false\n")},
+	}
+	md_cfg, err := NewMdConfig(fsys, "/md.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	local_cfg, err := NewMdConfig(fsys, "/doc/local.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m2h := NewMd2Html(&Md2HtmlConfig{
+		MdConfig:     md_cfg,
+		SystemFS:     fsys,
+		FrontMatter:  FrontMatterConfig{Yaml: true},
+		StartMdFile:  "/doc/index.md",
+		DocumentRoot: "/doc",
+	}).NewLocalSpec(local_cfg)
+
+	res, err := m2h.ConvertWithDiagnostics([]byte("```mermaid\ngraph TD\n```\n\n[!INCLUDE [part](part.md)]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(res.Html), `class="language-mermaid"`); n != 2 {
+		t.Errorf("got %d mermaid blocks: %s", n, res.Html)
+	}
+	msgs := []string{}
+	for _, d := range res.Diagnostics {
+		msgs = append(msgs, d.String())
+	}
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "markdown_config outside document root") {
+		t.Errorf("got diagnostics %q", msgs)
+	}
+}
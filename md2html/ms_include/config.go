@@ -0,0 +1,42 @@
+package ms_include
+
+import (
+	"io"
+)
+
+type PartConvertHtmlFunc func(abs_file string, sel *Selector, w io.Writer) error
+
+type ErrorHandlerFunc func(*IncludeError)
+
+type Config struct {
+	PartConvertHtml PartConvertHtmlFunc
+	ErrorHandler    ErrorHandlerFunc
+}
+
+type Option interface {
+	SetMsIncludeOption(*Config)
+}
+
+type withPartConvertHtml struct {
+	value PartConvertHtmlFunc
+}
+
+func (o *withPartConvertHtml) SetMsIncludeOption(c *Config) {
+	c.PartConvertHtml = o.value
+}
+
+func WithPartConvertHtml(f PartConvertHtmlFunc) Option {
+	return &withPartConvertHtml{value: f}
+}
+
+type withErrorHandler struct {
+	value ErrorHandlerFunc
+}
+
+func (o *withErrorHandler) SetMsIncludeOption(c *Config) {
+	c.ErrorHandler = o.value
+}
+
+func WithErrorHandler(f ErrorHandlerFunc) Option {
+	return &withErrorHandler{value: f}
+}
@@ -0,0 +1,62 @@
+package ms_include
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+type IncludeError struct {
+	Err    error
+	File   string
+	Chain  []string
+	Line   int
+	Column int
+}
+
+func (e *IncludeError) Error() string {
+	return e.Err.Error() + ": " + strings.Join(e.Chain, " → ")
+}
+
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *IncludeError) IsLimit() bool {
+	return errors.Is(e.Err, ErrRecursiveInclude) ||
+		errors.Is(e.Err, ErrOverlyNestedInclude) ||
+		errors.Is(e.Err, ErrIncludeTooLarge)
+}
+
+func (e *IncludeError) DisplayChain() []string {
+	if len(e.Chain) == 0 {
+		return []string{}
+	}
+
+	top := path.Dir(e.Chain[0]) + "/"
+	lst := make([]string, len(e.Chain))
+	for i, f := range e.Chain {
+		if strings.HasPrefix(f, top) {
+			f = f[len(top):]
+		}
+		lst[i] = f
+	}
+
+	return lst
+}
+
+type chainLister interface {
+	Chain() []string
+}
+
+func newIncludeError(err error, ps PathStack, file string, pushed bool) *IncludeError {
+	chain := []string{}
+	if cl, ok := ps.(chainLister); ok {
+		chain = cl.Chain()
+	}
+	if !pushed {
+		chain = append(chain, file)
+	}
+
+	return &IncludeError{Err: err, File: file, Chain: chain}
+}
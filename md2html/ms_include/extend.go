@@ -14,12 +14,14 @@ type ConvertHtmlFunc func(abs_file string, w io.Writer) error
 type msIncludeExtension struct{
 	convertHtml ConvertHtmlFunc
 	pathStack PathStack
+	options []Option
 }
 
-func NewMsInclude(conv ConvertHtmlFunc, pstack PathStack) goldmark.Extender {
+func NewMsInclude(conv ConvertHtmlFunc, pstack PathStack, opts ...Option) goldmark.Extender {
 	return &msIncludeExtension{
 		convertHtml: conv,
 		pathStack: pstack,
+		options: opts,
 	}
 }
 
@@ -32,7 +34,7 @@ func (e *msIncludeExtension) Extend(m goldmark.Markdown) {
 
 	m.Renderer().AddOptions(
 		renderer.WithNodeRenderers(
-			util.Prioritized(NewIncludeHTMLRenderer(e.convertHtml, e.pathStack), 500),
+			util.Prioritized(NewIncludeHTMLRenderer(e.convertHtml, e.pathStack, e.options...), 500),
 		),
 	)
 }
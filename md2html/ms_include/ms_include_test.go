@@ -3,9 +3,12 @@ package ms_include
 import (
 	"testing"
 
+	"bytes"
 	"io"
 	"errors"
+	"fmt"
 	"path"
+	"strings"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/renderer/html"
@@ -45,3 +48,219 @@ func TestInclude(t *testing.T) {
 	)
 	testutil.DoTestCaseFile(markdown, "_test/include_line.txt", t, testutil.ParseCliCaseArg()...)
 }
+
+func test_part_convert(file string, sel *Selector, w io.Writer) error {
+	var sel_str string
+	switch {
+	case sel.Section != "":
+		sel_str = "section=" + sel.Section
+	case sel.Region != "":
+		sel_str = "region=" + sel.Region
+	default:
+		sel_str = fmt.Sprintf("lines=%d-%d", sel.LineStart, sel.LineEnd)
+	}
+
+	_, err := io.WriteString(w, "<code>include: "+file+" "+sel_str+"</code>")
+	return err
+}
+
+func TestIncludePart(t *testing.T) {
+	ps := NewSlicePathStack("/var/www/html/README.md")
+	markdown := goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+		goldmark.WithExtensions(
+			NewMsInclude(test_convert, ps, WithPartConvertHtml(test_part_convert)),
+		),
+	)
+	testutil.DoTestCaseFile(markdown, "_test/include_part.txt", t, testutil.ParseCliCaseArg()...)
+}
+
+func TestIncludeTraversed(t *testing.T) {
+	ps := NewSlicePathStack("/var/www/html/README.md")
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewMsInclude(test_convert, ps, WithPartConvertHtml(test_part_convert)),
+		),
+	)
+
+	src := "[!INCLUDE [a](a.md)]\n\n[!INCLUDE [b](b.md#setup)]\n\n[!INCLUDE [c](loop.md)]\n"
+	if err := markdown.Convert([]byte(src), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/var/www/html/a.md"}
+	if got := ps.Traversed(); len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSelectText(t *testing.T) {
+	src := []byte(`package main
+
+// #region imports
+import "fmt"
+// #endregion
+
+func main() {
+	// #region body
+	fmt.Println("a")
+	// #region inner
+	fmt.Println("b")
+	// #endregion
+	// #endregion
+}
+`)
+	cases := []struct {
+		sel  Selector
+		want string
+		err  error
+	}{
+		{Selector{LineStart: 3, LineEnd: 5}, "// #region imports\nimport \"fmt\"\n// #endregion\n", nil},
+		{Selector{LineStart: 14}, "}\n", nil},
+		{Selector{LineStart: 20}, "", ErrLineOutOfRange},
+		{Selector{Region: "imports"}, "import \"fmt\"\n", nil},
+		{Selector{Region: "body"}, "\tfmt.Println(\"a\")\n\tfmt.Println(\"b\")\n", nil},
+		{Selector{Region: "none"}, "", ErrRegionNotFound},
+	}
+
+	for _, c := range cases {
+		got, err := c.sel.SelectText(src)
+		if err != c.err {
+			t.Errorf("%+v: got error %v, want %v", c.sel, err, c.err)
+			continue
+		}
+		if string(got) != c.want {
+			t.Errorf("%+v: got %q, want %q", c.sel, got, c.want)
+		}
+	}
+
+	md := []byte("<!-- region note -->\nnote text\n# region setup\ntext\n# endregion\nmore\n<!-- endregion -->\n")
+	got, err := (&Selector{Region: "note"}).SelectText(md)
+	if err != nil || string(got) != "note text\n# region setup\ntext\n# endregion\nmore\n" {
+		t.Errorf("markdown region: got %q, %v", got, err)
+	}
+	if got, err := (&Selector{Region: "setup"}).SelectText(md); err != ErrRegionNotFound {
+		t.Errorf("markdown heading: got %q, %v", got, err)
+	}
+
+	py := []byte("#region setup\nimport os\n#endregion\n")
+	got, err = (&Selector{Region: "setup"}).SelectText(py)
+	if err != nil || string(got) != "import os\n" {
+		t.Errorf("#region: got %q, %v", got, err)
+	}
+}
+
+func TestIncludeError(t *testing.T) {
+	ps := NewSlicePathStack("/var/www/html/README.md")
+	if err := ps.Push("/var/www/html/a.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := []*IncludeError{}
+	markdown := goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+		goldmark.WithExtensions(
+			NewMsInclude(test_convert, ps, WithErrorHandler(func(e *IncludeError) {
+				errs = append(errs, e)
+			})),
+		),
+	)
+	testutil.DoTestCaseFile(markdown, "_test/include_error.txt", t, testutil.ParseCliCaseArg()...)
+
+	if len(errs) != 4 {
+		t.Fatalf("got %d errors, want 4", len(errs))
+	}
+	if !errors.Is(errs[2], ErrDummy) || errs[2].IsLimit() {
+		t.Errorf("got %v, want %v", errs[2], ErrDummy)
+	}
+}
+
+func TestIncludeDepthLimit(t *testing.T) {
+	ps := NewSlicePathStack("/var/www/html/README.md")
+	if err := ps.Push("/var/www/html/a.md"); err != nil {
+		t.Fatal(err)
+	}
+	ps.SetLimits(1, 0)
+
+	var inc_err *IncludeError
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewMsInclude(test_convert, ps, WithErrorHandler(func(e *IncludeError) {
+				inc_err = e
+			})),
+		),
+	)
+
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte("[!INCLUDE [title](b.md)]\n"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if inc_err == nil || !errors.Is(inc_err, ErrOverlyNestedInclude) {
+		t.Fatalf("got %v, want %v", inc_err, ErrOverlyNestedInclude)
+	}
+	if got := strings.Join(inc_err.DisplayChain(), " → "); got != "README.md → a.md → b.md" {
+		t.Errorf("got chain %q", got)
+	}
+	if ps.Depth() != 1 {
+		t.Errorf("got depth %d, want 1", ps.Depth())
+	}
+
+	if err := ps.AddBytes(10); err != nil {
+		t.Fatal(err)
+	}
+	ps.SetLimits(1, 15)
+	if err := ps.AddBytes(10); err != ErrIncludeTooLarge {
+		t.Errorf("got %v, want %v", err, ErrIncludeTooLarge)
+	}
+}
+
+func TestIncludeDefaultDepth(t *testing.T) {
+	ps := NewSlicePathStack("/doc/0.md")
+	ps.SetLimits(0, 0)
+
+	for i := 1; i <= maxIncludeDepth; i++ {
+		if err := ps.Push(fmt.Sprintf("/doc/%d.md", i)); err != nil {
+			t.Fatalf("depth %d: %v", i, err)
+		}
+	}
+	if err := ps.Push("/doc/last.md"); err != ErrOverlyNestedInclude {
+		t.Errorf("got %v, want %v", err, ErrOverlyNestedInclude)
+	}
+}
+
+func TestSlicePathStackEdges(t *testing.T) {
+	ps := NewSlicePathStack("/doc/a.md")
+	if err := ps.Push("/doc/b.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.Push("/doc/sub/../a.md"); err != ErrRecursiveInclude {
+		t.Fatalf("got %v, want %v", err, ErrRecursiveInclude)
+	}
+	ps.Pop()
+	if err := ps.Push("/doc/b.md"); err != nil {
+		t.Fatal(err)
+	}
+	ps.Pop()
+
+	want := []Edge{
+		{From: "/doc/a.md", To: "/doc/b.md"},
+		{From: "/doc/b.md", To: "/doc/a.md"},
+	}
+	got := ps.Edges()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if inc := ps.Included(); len(inc) != 1 || inc[0] != "/doc/b.md" {
+		t.Fatalf("got %v, want [/doc/b.md]", inc)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 var ErrRecursiveInclude = errors.New("recursive include")
 var ErrOverlyNestedInclude = errors.New("overly nested include")
+var ErrIncludeTooLarge = errors.New("include size limit exceeded")
 
 type PathStack interface {
 	Cwd() string
@@ -17,8 +18,20 @@ type PathStack interface {
 
 const maxIncludeDepth = 100
 
+type Edge struct {
+	From string
+	To   string
+}
+
 type SlicePathStack struct {
-	files []string
+	files     []string
+	included  []string
+	traversed []string
+	edges     []Edge
+
+	max_depth int
+	max_bytes int64
+	total     int64
 }
 
 func (ps *SlicePathStack) Depth() int {
@@ -26,18 +39,78 @@ func (ps *SlicePathStack) Depth() int {
 }
 
 func (ps *SlicePathStack) Push(file string) error {
-	if ps.Depth() > maxIncludeDepth {
+	file = path.Clean(file)
+	edge := Edge{From: ps.files[len(ps.files)-1], To: file}
+	if !slices.Contains(ps.edges, edge) {
+		ps.edges = append(ps.edges, edge)
+	}
+
+	if ps.Depth() >= ps.max_depth {
 		return ErrOverlyNestedInclude
 	}
 	if ps.Contains(file) {
 		return ErrRecursiveInclude
 	}
 
-	ps.files = append(ps.files, path.Clean(file))
+	ps.files = append(ps.files, file)
+	if !slices.Contains(ps.included, file) {
+		ps.included = append(ps.included, file)
+	}
 
 	return nil
 }
 
+func (ps *SlicePathStack) Included() []string {
+	return slices.Clone(ps.included)
+}
+
+// SetTraversed records that the whole of file was included and converted.
+func (ps *SlicePathStack) SetTraversed(file string) {
+	file = path.Clean(file)
+	if !slices.Contains(ps.traversed, file) {
+		ps.traversed = append(ps.traversed, file)
+	}
+}
+
+func (ps *SlicePathStack) Traversed() []string {
+	return slices.Clone(ps.traversed)
+}
+
+// SetLimits sets the include limits. A max_depth of zero or less means
+// the default depth, and a max_bytes of zero or less means no size limit.
+func (ps *SlicePathStack) SetLimits(max_depth int, max_bytes int64) {
+	if max_depth <= 0 {
+		max_depth = maxIncludeDepth
+	}
+	ps.max_depth = max_depth
+	ps.max_bytes = max_bytes
+}
+
+func (ps *SlicePathStack) AddBytes(n int) error {
+	ps.total += int64(n)
+	if ps.max_bytes > 0 && ps.total > ps.max_bytes {
+		return ErrIncludeTooLarge
+	}
+
+	return nil
+}
+
+func (ps *SlicePathStack) Chain() []string {
+	return slices.Clone(ps.files)
+}
+
+func (ps *SlicePathStack) Start() string {
+	if len(ps.files) <= 0 {
+		panic("Not initialized")
+	}
+
+	return ps.files[0]
+}
+
+func (ps *SlicePathStack) Edges() []Edge {
+	return slices.Clone(ps.edges)
+}
+
 func (ps *SlicePathStack) Cwd() string {
 	if len(ps.files) <= 0 {
 		panic("Not initialized")
@@ -59,5 +132,5 @@ func (ps *SlicePathStack) Contains(file string) bool {
 }
 
 func NewSlicePathStack(start_file string) *SlicePathStack {
-	return &SlicePathStack{files: []string{start_file}}
+	return &SlicePathStack{files: []string{start_file}, max_depth: maxIncludeDepth}
 }
@@ -3,22 +3,31 @@ package ms_include
 import (
 	"bytes"
 	"path"
+	"strings"
 
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
 )
 
 type IncludeHTMLRenderer struct {
+	Config
 	convertHtml ConvertHtmlFunc
 	pathStack   PathStack
 }
 
-func NewIncludeHTMLRenderer(conv ConvertHtmlFunc, ps PathStack) renderer.NodeRenderer {
-	return &IncludeHTMLRenderer{
+func NewIncludeHTMLRenderer(conv ConvertHtmlFunc, ps PathStack, opts ...Option) renderer.NodeRenderer {
+	r := &IncludeHTMLRenderer{
 		convertHtml: conv,
 		pathStack:   ps,
 	}
+	for _, opt := range opts {
+		opt.SetMsIncludeOption(&r.Config)
+	}
+
+	return r
 }
 
 func (r *IncludeHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
@@ -31,11 +40,15 @@ func (r *IncludeHTMLRenderer) renderInclude(w util.BufWriter, source []byte, nod
 	}
 
 	includeNode := node.(*IncludeNode)
-	file := string(includeNode.Link)
-	if !isFilePath(file) {
+	if !isFilePath(string(includeNode.Link)) {
 		return ast.WalkContinue, nil
 	}
 
+	file, sel, err := ParseLink(string(includeNode.Link))
+	if err != nil {
+		return r.renderError(w, source, node, newIncludeError(err, r.pathStack, string(includeNode.Link), false))
+	}
+
 	file = path.Clean(file)
 	if len(file) <= 0 {
 		return ast.WalkContinue, nil
@@ -48,16 +61,55 @@ func (r *IncludeHTMLRenderer) renderInclude(w util.BufWriter, source []byte, nod
 	}
 
 	if err := r.pathStack.Push(file); err != nil {
-		return ast.WalkContinue, nil
+		return r.renderError(w, source, node, newIncludeError(err, r.pathStack, file, false))
 	}
 
 	var buf bytes.Buffer
-	if e := r.convertHtml(file, &buf); e != nil {
-		return ast.WalkContinue, nil
+	if !sel.IsZero() && r.PartConvertHtml != nil {
+		err = r.PartConvertHtml(file, sel, &buf)
+	} else {
+		err = r.convertHtml(file, &buf)
+	}
+	if err != nil {
+		inc_err := newIncludeError(err, r.pathStack, file, true)
+		r.pathStack.Pop()
+		return r.renderError(w, source, node, inc_err)
+	}
+	if ts, ok := r.pathStack.(traversedSetter); ok && sel.IsZero() {
+		ts.SetTraversed(file)
 	}
+	r.pathStack.Pop()
+
 	buf.WriteTo(w)
+	return ast.WalkSkipChildren, nil
+}
 
-	r.pathStack.Pop()
+type traversedSetter interface {
+	SetTraversed(file string)
+}
+
+func (r *IncludeHTMLRenderer) renderError(w util.BufWriter, source []byte, node ast.Node, inc_err *IncludeError) (ast.WalkStatus, error) {
+	inc_err.Line, inc_err.Column = diag.Position(source, node.Pos())
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(inc_err)
+	}
+	if !inc_err.IsLimit() {
+		return ast.WalkContinue, nil
+	}
+
+	// a div cannot be nested in the p element of the paragraph.
+	_, in_para := node.Parent().(*ast.Paragraph)
+	if in_para {
+		_, _ = w.WriteString("</p>")
+	}
+	_, _ = w.WriteString(`<div class="markdown-include-error"><p class="markdown-include-error_title">`)
+	_, _ = w.Write(util.EscapeHTML([]byte("Include error: " + inc_err.Err.Error())))
+	_, _ = w.WriteString(`</p><p class="markdown-include-error_chain"><code>`)
+	_, _ = w.Write(util.EscapeHTML([]byte(strings.Join(inc_err.DisplayChain(), " → "))))
+	_, _ = w.WriteString("</code></p></div>")
+	if in_para {
+		_, _ = w.WriteString("<p>")
+	}
 
 	return ast.WalkSkipChildren, nil
 }
@@ -0,0 +1,170 @@
+package ms_include
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrBadSelector = errors.New("bad include selector")
+var ErrLineOutOfRange = errors.New("include line out of range")
+var ErrRegionNotFound = errors.New("include region not found")
+
+type Selector struct {
+	Section   string
+	Region    string
+	LineStart int
+	LineEnd   int
+}
+
+func (sel *Selector) IsZero() bool {
+	return sel == nil || *sel == Selector{}
+}
+
+func parseLineRange(v string) (int, int, error) {
+	s, e, has_range := strings.Cut(v, "-")
+	start, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || start < 1 {
+		return 0, 0, ErrBadSelector
+	}
+	if !has_range {
+		return start, start, nil
+	}
+
+	e = strings.TrimSpace(e)
+	if e == "" {
+		return start, 0, nil
+	}
+	end, err := strconv.Atoi(e)
+	if err != nil || end < start {
+		return 0, 0, ErrBadSelector
+	}
+
+	return start, end, nil
+}
+
+func ParseLink(link string) (string, *Selector, error) {
+	sel := &Selector{}
+
+	file, frag, has_frag := strings.Cut(link, "#")
+	if has_frag {
+		if frag == "" || strings.ContainsRune(frag, '?') {
+			return "", nil, ErrBadSelector
+		}
+		sel.Section = frag
+	}
+
+	file, query, has_query := strings.Cut(file, "?")
+	if has_query {
+		q, err := url.ParseQuery(query)
+		if err != nil {
+			return "", nil, ErrBadSelector
+		}
+
+		for k, v := range q {
+			if len(v) != 1 {
+				return "", nil, ErrBadSelector
+			}
+			switch k {
+			case "lines":
+				sel.LineStart, sel.LineEnd, err = parseLineRange(v[0])
+				if err != nil {
+					return "", nil, err
+				}
+			case "region":
+				if v[0] == "" {
+					return "", nil, ErrBadSelector
+				}
+				sel.Region = v[0]
+			default:
+				return "", nil, ErrBadSelector
+			}
+		}
+	}
+
+	if sel.LineStart > 0 && sel.Region != "" {
+		return "", nil, ErrBadSelector
+	}
+	if sel.Section != "" && (sel.LineStart > 0 || sel.Region != "") {
+		return "", nil, ErrBadSelector
+	}
+
+	return file, sel, nil
+}
+
+// A bare # leader needs the #region form without a space, so that a markdown
+// heading such as "# region setup" is not taken as a region marker.
+const regionLeader = `^\s*(?:(?:<!--|//|--|;|%|/\*|\(\*|')\s*#?|#)`
+
+var regionStartRegexp = regexp.MustCompile(regionLeader + `region\b\s*(.*?)\s*(?:-->|\*/|\*\))?\s*$`)
+var regionEndRegexp = regexp.MustCompile(regionLeader + `endregion\b`)
+
+func splitLines(src []byte) [][]byte {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func (sel *Selector) selectLines(src []byte) ([]byte, error) {
+	lines := splitLines(src)
+	if sel.LineStart > len(lines) {
+		return nil, ErrLineOutOfRange
+	}
+
+	end := sel.LineEnd
+	if end == 0 || end > len(lines) {
+		end = len(lines)
+	}
+
+	return bytes.Join(lines[sel.LineStart-1:end], nil), nil
+}
+
+func (sel *Selector) selectRegion(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	depth := -1
+	for _, line := range splitLines(src) {
+		trim := bytes.TrimRight(line, "\r\n")
+		if m := regionStartRegexp.FindSubmatch(trim); m != nil {
+			switch {
+			case depth >= 0:
+				depth++
+			case string(m[1]) == sel.Region:
+				depth = 0
+			}
+			continue
+		}
+		if regionEndRegexp.Match(trim) {
+			if depth == 0 {
+				return buf.Bytes(), nil
+			}
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+
+		if depth >= 0 {
+			buf.Write(line)
+		}
+	}
+
+	return nil, ErrRegionNotFound
+}
+
+func (sel *Selector) SelectText(src []byte) ([]byte, error) {
+	switch {
+	case sel.IsZero():
+		return src, nil
+	case sel.LineStart > 0:
+		return sel.selectLines(src)
+	case sel.Region != "":
+		return sel.selectRegion(src)
+	}
+
+	return src, nil
+}
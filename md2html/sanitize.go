@@ -1,10 +1,17 @@
 package md2html
 
 import (
+	"bytes"
 	_ "embed"
+	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/naoina/toml"
 	"github.com/sym01/htmlsanitizer"
+	"golang.org/x/net/html"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
 )
 
 //go:embed htmlsanitizer.conf
@@ -12,6 +19,11 @@ var htmlAllowToml []byte
 
 var htmlAllowList = mustUnmarshalAllowList(htmlAllowToml)
 
+//go:embed svg_sanitizer.conf
+var svgAllowToml []byte
+
+var svgAllowList = mergeAllowList(htmlAllowList, mustUnmarshalAllowList(svgAllowToml))
+
 func mustUnmarshalAllowList(cnf []byte) *htmlsanitizer.AllowList {
 	v := &htmlsanitizer.AllowList{}
 	err := toml.Unmarshal(cnf, v)
@@ -22,6 +34,26 @@ func mustUnmarshalAllowList(cnf []byte) *htmlsanitizer.AllowList {
 	return v
 }
 
+// mergeAllowList adds the tags and attributes of ext to base.
+// Tags allowed by ext are no longer treated as non-HTML tags.
+func mergeAllowList(base *htmlsanitizer.AllowList, ext *htmlsanitizer.AllowList) *htmlsanitizer.AllowList {
+	v := base.Clone()
+	v.GlobalAttr = append(v.GlobalAttr, ext.GlobalAttr...)
+	for _, tag := range ext.Tags {
+		if cur := v.FindTag(tag.Name); cur != nil {
+			cur.Attr = append(cur.Attr, tag.Attr...)
+			cur.URLAttr = append(cur.URLAttr, tag.URLAttr...)
+			continue
+		}
+		v.Tags = append(v.Tags, tag)
+		v.NonHTMLTags = slices.DeleteFunc(v.NonHTMLTags, func(t *htmlsanitizer.Tag) bool {
+			return t.Name == tag.Name
+		})
+	}
+
+	return v.Clone()
+}
+
 type sanitaizer struct {
 	impl *htmlsanitizer.HTMLSanitizer
 }
@@ -32,6 +64,47 @@ func newSanitizer() *sanitaizer {
 	return &sanitaizer{impl: impl}
 }
 
+func newSvgSanitizer() *sanitaizer {
+	impl := htmlsanitizer.NewHTMLSanitizer()
+	impl.AllowList = svgAllowList.Clone()
+	return &sanitaizer{impl: impl}
+}
+
 func (san *sanitaizer) Sanitize(src_html []byte) ([]byte, error) {
 	return san.impl.Sanitize(src_html)
 }
+
+func countElements(html_bin []byte) map[string]int {
+	cnt := map[string]int{}
+	z := html.NewTokenizer(bytes.NewReader(html_bin))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return cnt
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			cnt[string(name)]++
+		}
+	}
+}
+
+func (m2h *Md2Html) reportStripped(src_html []byte, dst_html []byte) {
+	src_cnt := countElements(src_html)
+	dst_cnt := countElements(dst_html)
+
+	names := []string{}
+	for name, n := range src_cnt {
+		if n > dst_cnt[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m2h.Report(&diag.Diagnostic{
+			Severity: diag.Warning,
+			Message: fmt.Sprintf("sanitizer removed %d <%s> element(s)",
+				src_cnt[name]-dst_cnt[name], name),
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package md2html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSvgSanitizerStyle(t *testing.T) {
+	src := `<svg viewBox="0 0 10 10"><style>@import url(https://example.com/x.css); .a{fill:url(https://example.com/b)}</style>` +
+		`<g transform="translate(1,1)"><rect fill="#fff" stroke="#000" width="5" height="5"></rect></g>` +
+		`<foreignObject><p style="background:url(https://example.com/c)">label</p></foreignObject></svg>`
+
+	out, err := newSvgSanitizer().Sanitize([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := string(out)
+	for _, bad := range []string{"<style", "@import", "example.com"} {
+		if strings.Contains(got, bad) {
+			t.Errorf("%q remains in %s", bad, got)
+		}
+	}
+	for _, good := range []string{`transform="translate(1,1)"`, `fill="#fff"`, `stroke="#000"`, "label"} {
+		if !strings.Contains(got, good) {
+			t.Errorf("%q is removed from %s", good, got)
+		}
+	}
+}
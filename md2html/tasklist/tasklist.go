@@ -2,6 +2,7 @@ package tasklist
 
 import (
 	"regexp"
+	"strconv"
 
 	"github.com/yuin/goldmark"
 	gast "github.com/yuin/goldmark/ast"
@@ -15,6 +16,51 @@ import (
 
 var taskListRegexp = regexp.MustCompile(`^\[([\sxX])\]\s`)
 
+var taskMarksKey = parser.NewContextKey()
+var taskCountKey = parser.NewContextKey()
+
+// TaskCount is the number of the task items of a document.
+type TaskCount struct {
+	Total int
+	Done  int
+}
+
+func (tc TaskCount) Open() int {
+	return tc.Total - tc.Done
+}
+
+// Percent returns the done ratio in percent, rounded down.
+func (tc TaskCount) Percent() int {
+	if tc.Total == 0 {
+		return 0
+	}
+	return tc.Done * 100 / tc.Total
+}
+
+// Count returns the number of the task items parsed with pc.
+func Count(pc parser.Context) TaskCount {
+	if tc, ok := pc.Get(taskCountKey).(TaskCount); ok {
+		return tc
+	}
+	return TaskCount{}
+}
+
+// EnableTaskIndex makes the parser number the task checkboxes of the
+// document in source order. The numbers are rendered as data-task-index
+// attributes and the positions of the marks are returned by TaskMarks.
+func EnableTaskIndex(pc parser.Context) {
+	pc.Set(taskMarksKey, []int{})
+}
+
+// TaskMarks returns the source offsets of the mark characters ('x', 'X' or
+// space) of the numbered task checkboxes.
+func TaskMarks(pc parser.Context) []int {
+	if marks, ok := pc.Get(taskMarksKey).([]int); ok {
+		return marks
+	}
+	return nil
+}
+
 type taskCheckBoxParser struct {
 }
 
@@ -44,7 +90,7 @@ func (s *taskCheckBoxParser) Parse(parent gast.Node, block text.Reader, pc parse
 	if _, ok := parent.Parent().(*gast.ListItem); !ok {
 		return nil
 	}
-	line, _ := block.PeekLine()
+	line, seg := block.PeekLine()
 	m := taskListRegexp.FindSubmatchIndex(line)
 	if m == nil {
 		return nil
@@ -52,7 +98,20 @@ func (s *taskCheckBoxParser) Parse(parent gast.Node, block text.Reader, pc parse
 	value := line[m[2]:m[3]][0]
 	block.Advance(m[1])
 	checked := value == 'x' || value == 'X'
-	return ast.NewTaskCheckBox(checked)
+	n := ast.NewTaskCheckBox(checked)
+
+	tc := Count(pc)
+	tc.Total++
+	if checked {
+		tc.Done++
+	}
+	pc.Set(taskCountKey, tc)
+
+	if marks, ok := pc.Get(taskMarksKey).([]int); ok {
+		n.SetAttributeString("data-task-index", []byte(strconv.Itoa(len(marks))))
+		pc.Set(taskMarksKey, append(marks, seg.Start+m[2]))
+	}
+	return n
 }
 
 func (s *taskCheckBoxParser) CloseBlock(parent gast.Node, pc parser.Context) {
@@ -92,6 +151,9 @@ func (r *TaskCheckBoxHTMLRenderer) renderTaskCheckBox(w util.BufWriter, source [
 	} else {
 		w.WriteString(`<input disabled="" type="checkbox"`)
 	}
+	if n.Attributes() != nil {
+		html.RenderAttributes(w, n, nil)
+	}
 	if r.XHTML {
 		w.WriteString(" /> ")
 	} else {
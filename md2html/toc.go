@@ -2,6 +2,8 @@ package md2html
 
 import (
 	"bytes"
+	"strconv"
+	"strings"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
@@ -11,11 +13,22 @@ type Toc struct {
 	Title      string
 	TitleLevel int
 	Heads      []*Head
+	Tables     []*TableRef
+	BaseLevel  int
+
+	all []*Head
 }
 type Head struct {
-	Id    string
-	Text  string
-	Level int
+	Id     string
+	Text   string
+	Level  int
+	Number string
+
+	no_toc bool
+}
+type TableRef struct {
+	Id      string
+	Caption string
 }
 
 func NewToc(html_bin []byte) (*Toc, error) {
@@ -25,7 +38,7 @@ func NewToc(html_bin []byte) (*Toc, error) {
 		return nil, err
 	}
 
-	tc := &Toc{Heads: []*Head{}}
+	tc := &Toc{Heads: []*Head{}, Tables: []*TableRef{}}
 	tc.find_head(root)
 	return tc, nil
 }
@@ -53,10 +66,17 @@ e_loop:
 			lv = 6
 		}
 		if lv == 0 {
+			if e.DataAtom == atom.Table {
+				tc.add_table(e)
+			}
 			tc.find_head(e)
 		} else {
 			h := tc.get_head(lv, e)
-			tc.Heads = append(tc.Heads, h)
+			h.no_toc = hasClass(e, "no-toc")
+			tc.all = append(tc.all, h)
+			if !h.no_toc {
+				tc.Heads = append(tc.Heads, h)
+			}
 
 			if tc.TitleLevel == 0 || lv < tc.TitleLevel {
 				tc.TitleLevel = lv
@@ -66,6 +86,129 @@ e_loop:
 	}
 }
 
+func hasClass(e *html.Node, class string) bool {
+	for _, a := range e.Attr {
+		if a.Key == "class" {
+			for _, c := range strings.Fields(a.Val) {
+				if c == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Filter drops the headings outside of the configured levels, the single
+// H1 title and the no-toc headings with their subsections, and numbers the
+// remaining headings.
+func (tc *Toc) Filter(opts TocOptions) {
+	opts = opts.fix()
+
+	base := opts.MinLevel
+	if opts.DropTitle {
+		h1 := 0
+		for _, h := range tc.all {
+			if h.Level == 1 && !h.no_toc {
+				h1++
+			}
+		}
+		if h1 == 1 && base == 1 {
+			base = 2
+		}
+	}
+
+	type counter struct {
+		level int
+		num   int
+	}
+	cnt := []counter{}
+	heads := []*Head{}
+	hide := 0
+	for _, h := range tc.all {
+		if hide > 0 && h.Level > hide {
+			continue
+		}
+		hide = 0
+		if h.no_toc {
+			hide = h.Level
+			continue
+		}
+		if h.Level < base || h.Level > opts.MaxLevel {
+			continue
+		}
+		heads = append(heads, h)
+
+		// a skipped level is not numbered, so H2, H4, H3 is 1, 1.1, 1.2.
+		last := 0
+		for len(cnt) > 0 && cnt[len(cnt)-1].level > h.Level {
+			last = cnt[len(cnt)-1].num
+			cnt = cnt[:len(cnt)-1]
+		}
+		if len(cnt) > 0 && cnt[len(cnt)-1].level == h.Level {
+			cnt[len(cnt)-1].num++
+		} else {
+			cnt = append(cnt, counter{level: h.Level, num: last + 1})
+		}
+
+		h.Number = ""
+		if opts.Numbered {
+			nums := make([]string, len(cnt))
+			for i, c := range cnt {
+				nums[i] = strconv.Itoa(c.num)
+			}
+			h.Number = strings.Join(nums, ".")
+		}
+	}
+	tc.Heads = heads
+	tc.BaseLevel = base
+}
+
+// NumberHeadings inserts the heading numbers into the headings of html_bin.
+func (tc *Toc) NumberHeadings(html_bin []byte) []byte {
+	nums := map[string]string{}
+	for _, h := range tc.Heads {
+		if h.Id != "" && h.Number != "" {
+			nums[h.Id] = h.Number
+		}
+	}
+	if len(nums) == 0 {
+		return html_bin
+	}
+
+	var buf bytes.Buffer
+	z := html.NewTokenizer(bytes.NewReader(html_bin))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		buf.Write(z.Raw())
+		if tt != html.StartTagToken {
+			continue
+		}
+
+		tok := z.Token()
+		switch tok.DataAtom {
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		default:
+			continue
+		}
+		for _, a := range tok.Attr {
+			if a.Key != "id" {
+				continue
+			}
+			if num, ok := nums[a.Val]; ok {
+				buf.WriteString(`<span class="toc-number">`)
+				buf.WriteString(num)
+				buf.WriteString("</span> ")
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
 func (tc *Toc) get_head(lv int, e *html.Node) *Head {
 	var txt_buf bytes.Buffer
 	id := ""
@@ -82,10 +225,59 @@ func (tc *Toc) get_head(lv int, e *html.Node) *Head {
 	return &Head{Id: id, Level: lv, Text: txt_buf.String()}
 }
 
+func (tc *Toc) add_table(e *html.Node) {
+	id := ""
+	for _, a := range e.Attr {
+		if a.Key == "id" && a.Val != "" {
+			id = a.Val
+			break
+		}
+	}
+	if id == "" {
+		return
+	}
+
+	for c := e.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Caption {
+			continue
+		}
+
+		var txt_buf bytes.Buffer
+		if err := convertHtmlNodeText(c, &txt_buf); err != nil {
+			return
+		}
+		tc.Tables = append(tc.Tables, &TableRef{Id: id, Caption: txt_buf.String()})
+		return
+	}
+}
+
+func (tc *Toc) ConvertTablesHtml() []byte {
+	var buf bytes.Buffer
+	if len(tc.Tables) == 0 {
+		return buf.Bytes()
+	}
+
+	buf.WriteString("<ul>")
+	for _, t := range tc.Tables {
+		buf.WriteString("<li><a href=\"#")
+		buf.WriteString(html.EscapeString(t.Id))
+		buf.WriteString("\">")
+		buf.WriteString(html.EscapeString(t.Caption))
+		buf.WriteString("</a></li>")
+	}
+	buf.WriteString("</ul>")
+
+	return buf.Bytes()
+}
+
 func (tc *Toc) ConvertHtml() []byte {
 	var buf bytes.Buffer
 
 	lv := 0
+	if tc.BaseLevel > 1 {
+		lv = tc.BaseLevel - 1
+	}
+	base := lv
 	for _, h := range tc.Heads {
 		for h.Level != lv {
 			if h.Level > lv {
@@ -100,10 +292,15 @@ func (tc *Toc) ConvertHtml() []byte {
 		buf.WriteString("<li><a href=\"#")
 		buf.WriteString(html.EscapeString(h.Id))
 		buf.WriteString("\">")
+		if h.Number != "" {
+			buf.WriteString(`<span class="toc-number">`)
+			buf.WriteString(h.Number)
+			buf.WriteString("</span> ")
+		}
 		buf.WriteString(html.EscapeString(h.Text))
 		buf.WriteString("</a></li>")
 	}
-	for lv > 0 {
+	for lv > base {
 		lv--
 		buf.WriteString("</ul>")
 	}
@@ -0,0 +1,100 @@
+package md2html
+
+import (
+	"strings"
+	"testing"
+)
+
+const tocTestHtml = `<h1 id="title">Title</h1>
+<h2 id="a">A</h2>
+<h4 id="a1">A1</h4>
+<h3 id="a2">A2</h3>
+<h2 id="x" class="no-toc">X</h2>
+<h3 id="x1">X1</h3>
+<h2 id="b">B</h2>
+<h3 id="b1">B1</h3>
+`
+
+func tocTestHeads(heads []*Head) string {
+	s := []string{}
+	for _, h := range heads {
+		if h.Number != "" {
+			s = append(s, h.Id+":"+h.Number)
+		} else {
+			s = append(s, h.Id)
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+func TestTocFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TocOptions
+		want string
+		base int
+	}{
+		{"default", TocOptions{},
+			"title a a1 a2 b b1", 1},
+		{"numbered", TocOptions{Numbered: true},
+			"title:1 a:1.1 a1:1.1.1 a2:1.1.2 b:1.2 b1:1.2.1", 1},
+		{"drop_title", TocOptions{Numbered: true, DropTitle: true},
+			"a:1 a1:1.1 a2:1.2 b:2 b1:2.1", 2},
+		{"min_level", TocOptions{Numbered: true, MinLevel: 3},
+			"a1:1 a2:2 b1:3", 3},
+		{"max_level", TocOptions{Numbered: true, MinLevel: 2, MaxLevel: 3},
+			"a:1 a2:1.1 b:2 b1:2.1", 2},
+	}
+
+	for _, tt := range tests {
+		tc, err := NewToc([]byte(tocTestHtml))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tc.Filter(tt.opts)
+		if got := tocTestHeads(tc.Heads); got != tt.want {
+			t.Errorf("%s: heads = %q, want %q", tt.name, got, tt.want)
+		}
+		if tc.BaseLevel != tt.base {
+			t.Errorf("%s: base level = %d, want %d", tt.name, tc.BaseLevel, tt.base)
+		}
+	}
+}
+
+func TestTocDropTitleMultiH1(t *testing.T) {
+	tc, err := NewToc([]byte(`<h1 id="a">A</h1><h2 id="a1">A1</h2><h1 id="b">B</h1>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.Filter(TocOptions{Numbered: true, DropTitle: true})
+	if got, want := tocTestHeads(tc.Heads), "a:1 a1:1.1 b:2"; got != want {
+		t.Errorf("heads = %q, want %q", got, want)
+	}
+}
+
+func TestTocNumberHeadings(t *testing.T) {
+	tc, err := NewToc([]byte(tocTestHtml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.Filter(TocOptions{Numbered: true, DropTitle: true})
+
+	got := string(tc.NumberHeadings([]byte(tocTestHtml)))
+	for _, want := range []string{
+		`<h1 id="title">Title</h1>`,
+		`<h2 id="a"><span class="toc-number">1</span> A</h2>`,
+		`<h4 id="a1"><span class="toc-number">1.1</span> A1</h4>`,
+		`<h2 id="x" class="no-toc">X</h2>`,
+		`<h3 id="x1">X1</h3>`,
+		`<h3 id="b1"><span class="toc-number">2.1</span> B1</h3>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q is not in %s", want, got)
+		}
+	}
+
+	tc.Filter(TocOptions{})
+	if got := string(tc.NumberHeadings([]byte(tocTestHtml))); got != tocTestHtml {
+		t.Errorf("unnumbered toc changes the html: %s", got)
+	}
+}
@@ -0,0 +1,31 @@
+package tocmark
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+type TocMarkNode struct {
+	ast.BaseBlock
+	Token string
+}
+
+func (n *TocMarkNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Token": n.Token,
+	}, nil)
+}
+
+var KindTocMark = ast.NewNodeKind("TocMark")
+
+func (n *TocMarkNode) Kind() ast.NodeKind {
+	return KindTocMark
+}
+
+func NewTocMarkNode(token string) *TocMarkNode {
+	n := &TocMarkNode{
+		BaseBlock: ast.BaseBlock{},
+		Token:     token,
+	}
+	n.SetAttributeString("class", "markdown-toc")
+	return n
+}
@@ -0,0 +1,51 @@
+package tocmark
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// DefaultToken is the placeholder text written into the TOC block.
+// The caller replaces it with the TOC built from the converted HTML.
+const DefaultToken = "[TOC]"
+
+type Config struct {
+	Token string
+}
+
+type Option interface {
+	SetTocMarkOption(*Config)
+}
+
+type withToken struct {
+	value string
+}
+
+func (o *withToken) SetTocMarkOption(c *Config) {
+	c.Token = o.value
+}
+
+func WithToken(token string) Option {
+	return &withToken{value: token}
+}
+
+func NewTocMark(opts ...Option) goldmark.Extender {
+	return &tocMarkExtension{
+		options: opts,
+	}
+}
+
+type tocMarkExtension struct {
+	options []Option
+}
+
+func (e *tocMarkExtension) Extend(md goldmark.Markdown) {
+	md.Parser().AddOptions(parser.WithBlockParsers(
+		util.Prioritized(NewTocMarkParser(e.options...), 100),
+	))
+	md.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(NewTocMarkRenderer(), 500),
+	))
+}
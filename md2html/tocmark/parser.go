@@ -0,0 +1,73 @@
+package tocmark
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var tocMarks = [][]byte{
+	[]byte("[TOC]"),
+	[]byte("[[_TOC_]]"),
+}
+
+func isTocMark(line []byte) bool {
+	if w, _ := util.IndentWidth(line, 0); w > 3 {
+		return false
+	}
+	line = bytes.TrimSpace(line)
+	for _, m := range tocMarks {
+		if bytes.Equal(line, m) {
+			return true
+		}
+	}
+	return false
+}
+
+type tocMarkParser struct {
+	Config
+}
+
+// NewTocMarkParser returns a BlockParser that parses a "[TOC]" or
+// "[[_TOC_]]" line.
+func NewTocMarkParser(opts ...Option) parser.BlockParser {
+	p := &tocMarkParser{
+		Config: Config{Token: DefaultToken},
+	}
+	for _, o := range opts {
+		o.SetTocMarkOption(&p.Config)
+	}
+	return p
+}
+
+func (b *tocMarkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (b *tocMarkParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	if !isTocMark(line) {
+		return nil, parser.NoChildren
+	}
+	reader.AdvanceLine()
+
+	return NewTocMarkNode(b.Token), parser.NoChildren
+}
+
+func (b *tocMarkParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return parser.Close
+}
+
+func (b *tocMarkParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+}
+
+func (b *tocMarkParser) CanInterruptParagraph() bool {
+	return false
+}
+
+func (b *tocMarkParser) CanAcceptIndentedLine() bool {
+	return false
+}
@@ -0,0 +1,33 @@
+package tocmark
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+type tocMarkRenderer struct{}
+
+func NewTocMarkRenderer() renderer.NodeRenderer {
+	return &tocMarkRenderer{}
+}
+
+func (r *tocMarkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindTocMark, r.renderTocMark)
+}
+
+func (r *tocMarkRenderer) renderTocMark(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*TocMarkNode)
+	_, _ = w.WriteString("<nav")
+	html.RenderAttributes(w, n, html.GlobalAttributeFilter)
+	_ = w.WriteByte('>')
+	_, _ = w.Write(util.EscapeHTML([]byte(n.Token)))
+	_, _ = w.WriteString("</nav>\n")
+
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,35 @@
+package tocmark
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestTocMark(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			NewTocMark(WithToken("toc-token")),
+		),
+	)
+
+	src := []byte("Intro\n\n[TOC]\n\n  [[_TOC_]]  \n\ntext\n[TOC]\n\n[TOC] more\n\n    [TOC]\n")
+	var buf bytes.Buffer
+	if err := markdown.Convert(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<p>Intro</p>
+<nav class="markdown-toc">toc-token</nav>
+<nav class="markdown-toc">toc-token</nav>
+<p>text
+[TOC]</p>
+<p>[TOC] more</p>
+<pre><code>[TOC]
+</code></pre>
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,17 @@
+package uniqid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewToken returns prefix with a random nonce appended, for the placeholder
+// text that is replaced after the rendering. Document text does not know
+// the nonce, so it cannot forge a placeholder.
+func NewToken(prefix string) string {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("uniqid: nonce error: " + err.Error())
+	}
+	return prefix + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,77 @@
+package xref
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+// A XrefNode is a [@kind:name] reference.
+type XrefNode struct {
+	ast.BaseInline
+	Label   string
+	Target  string
+	Display string
+}
+
+func (n *XrefNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Label":   n.Label,
+		"Target":  n.Target,
+		"Display": n.Display,
+	}, nil)
+}
+
+var KindXref = ast.NewNodeKind("Xref")
+
+func (n *XrefNode) Kind() ast.NodeKind {
+	return KindXref
+}
+
+func NewXrefNode(label string) *XrefNode {
+	return &XrefNode{Label: label}
+}
+
+// A NumberNode is a number label inserted before captions and headings.
+type NumberNode struct {
+	ast.BaseInline
+	Number string
+}
+
+func (n *NumberNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Number": n.Number}, nil)
+}
+
+var KindNumber = ast.NewNodeKind("XrefNumber")
+
+func (n *NumberNode) Kind() ast.NodeKind {
+	return KindNumber
+}
+
+func NewNumberNode(number string) *NumberNode {
+	return &NumberNode{Number: number}
+}
+
+// A FigureNode wraps a captioned image or code listing.
+type FigureNode struct {
+	ast.BaseBlock
+	Number  string
+	Caption string
+}
+
+func (n *FigureNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Number":  n.Number,
+		"Caption": n.Caption,
+	}, nil)
+}
+
+var KindFigure = ast.NewNodeKind("XrefFigure")
+
+func (n *FigureNode) Kind() ast.NodeKind {
+	return KindFigure
+}
+
+func NewFigureNode(class string, number string, caption string) *FigureNode {
+	n := &FigureNode{Number: number, Caption: caption}
+	n.SetAttributeString("class", class)
+	return n
+}
@@ -0,0 +1,105 @@
+package xref
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+type Names struct {
+	Figure  string
+	Table   string
+	Listing string
+	Section string
+}
+
+var DefaultNames = Names{
+	Figure:  "Figure",
+	Table:   "Table",
+	Listing: "Listing",
+	Section: "Section",
+}
+
+type Config struct {
+	Names          Names
+	NumberSections bool
+	Report         diag.ReportFunc
+}
+
+type Option interface {
+	SetXrefOption(*Config)
+}
+
+type withNames struct {
+	value Names
+}
+
+func (o *withNames) SetXrefOption(c *Config) {
+	if o.value.Figure != "" {
+		c.Names.Figure = o.value.Figure
+	}
+	if o.value.Table != "" {
+		c.Names.Table = o.value.Table
+	}
+	if o.value.Listing != "" {
+		c.Names.Listing = o.value.Listing
+	}
+	if o.value.Section != "" {
+		c.Names.Section = o.value.Section
+	}
+}
+
+func WithNames(n Names) Option {
+	return &withNames{value: n}
+}
+
+type withNumberSections struct {
+	value bool
+}
+
+func (o *withNumberSections) SetXrefOption(c *Config) {
+	c.NumberSections = o.value
+}
+
+func WithNumberSections(b bool) Option {
+	return &withNumberSections{value: b}
+}
+
+type withReport struct {
+	value diag.ReportFunc
+}
+
+func (o *withReport) SetXrefOption(c *Config) {
+	c.Report = o.value
+}
+
+func WithReport(f diag.ReportFunc) Option {
+	return &withReport{value: f}
+}
+
+func NewXref(opts ...Option) goldmark.Extender {
+	return &xrefExtension{
+		options: opts,
+	}
+}
+
+type xrefExtension struct {
+	options []Option
+}
+
+func (e *xrefExtension) Extend(md goldmark.Markdown) {
+	md.Parser().AddOptions(
+		parser.WithInlineParsers(
+			util.Prioritized(NewXrefParser(), 100),
+		),
+		parser.WithASTTransformers(
+			util.Prioritized(NewXrefTransformer(e.options...), 1000),
+		),
+	)
+	md.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(NewXrefRenderer(), 500),
+	))
+}
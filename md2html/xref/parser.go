@@ -0,0 +1,37 @@
+package xref
+
+import (
+	"regexp"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+var xrefRegexp = regexp.MustCompile(`^\[@((?:fig|tbl|lst|sec):[^\]\s]+)\]`)
+
+type xrefParser struct{}
+
+var defaultXrefParser = &xrefParser{}
+
+// NewXrefParser returns an InlineParser that parses [@kind:name] references.
+func NewXrefParser() parser.InlineParser {
+	return defaultXrefParser
+}
+
+func (s *xrefParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (s *xrefParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	m := xrefRegexp.FindSubmatch(line)
+	if m == nil {
+		return nil
+	}
+
+	block.Advance(len(m[0]))
+	n := NewXrefNode(string(m[1]))
+	n.SetPos(segment.Start)
+	return n
+}
@@ -0,0 +1,79 @@
+package xref
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+type xrefRenderer struct{}
+
+func NewXrefRenderer() renderer.NodeRenderer {
+	return &xrefRenderer{}
+}
+
+func (r *xrefRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindXref, r.renderXref)
+	reg.Register(KindNumber, r.renderNumber)
+	reg.Register(KindFigure, r.renderFigure)
+}
+
+func (r *xrefRenderer) renderXref(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*XrefNode)
+	if n.Target == "" {
+		_, _ = w.WriteString(`<span class="xref-unresolved">[@`)
+		_, _ = w.Write(util.EscapeHTML([]byte(n.Label)))
+		_, _ = w.WriteString("]</span>")
+		return ast.WalkSkipChildren, nil
+	}
+
+	_, _ = w.WriteString(`<a class="xref" href="#`)
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape([]byte(n.Target), false)))
+	_, _ = w.WriteString(`">`)
+	_, _ = w.Write(util.EscapeHTML([]byte(n.Display)))
+	_, _ = w.WriteString("</a>")
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *xrefRenderer) renderNumber(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*NumberNode)
+	_, _ = w.WriteString(`<span class="xref-number">`)
+	_, _ = w.Write(util.EscapeHTML([]byte(n.Number)))
+	_, _ = w.WriteString("</span> ")
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *xrefRenderer) renderFigure(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*FigureNode)
+	if entering {
+		_, _ = w.WriteString("<figure")
+		html.RenderAttributes(w, n, html.GlobalAttributeFilter)
+		_, _ = w.WriteString(">\n")
+		return ast.WalkContinue, nil
+	}
+
+	if _, ok := n.LastChild().(*ast.Image); ok {
+		_ = w.WriteByte('\n')
+	}
+	_, _ = w.WriteString(`<figcaption><span class="xref-number">`)
+	_, _ = w.Write(util.EscapeHTML([]byte(n.Number + ":")))
+	_, _ = w.WriteString("</span>")
+	if n.Caption != "" {
+		_ = w.WriteByte(' ')
+		_, _ = w.Write(util.EscapeHTML([]byte(n.Caption)))
+	}
+	_, _ = w.WriteString("</figcaption>\n</figure>\n")
+
+	return ast.WalkContinue, nil
+}
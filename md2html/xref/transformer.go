@@ -0,0 +1,328 @@
+package xref
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+	dtast "github.com/1f408/cats_eeds/md2html/dt_table/ast"
+)
+
+type target struct {
+	id      []byte
+	display string
+}
+
+type xrefTransformer struct {
+	Config
+}
+
+func NewXrefTransformer(opts ...Option) parser.ASTTransformer {
+	t := &xrefTransformer{
+		Config: Config{Names: DefaultNames},
+	}
+	for _, opt := range opts {
+		opt.SetXrefOption(&t.Config)
+	}
+
+	return t
+}
+
+func (t *xrefTransformer) report(src []byte, pos int, msg string) {
+	if t.Report == nil {
+		return
+	}
+
+	d := &diag.Diagnostic{Severity: diag.Warning, Message: msg}
+	d.Line, d.Column = diag.Position(src, pos)
+	t.Report(d)
+}
+
+func nodePos(n ast.Node) int {
+	if n.Lines().Len() > 0 {
+		return n.Lines().At(0).Start
+	}
+	return n.Pos()
+}
+
+func plainText(n ast.Node, src []byte) string {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := c.(type) {
+		case *ast.Text:
+			buf.Write(c.Value(src))
+			if c.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(c.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(buf.String())
+}
+
+func labelOf(n ast.Node, kind string) string {
+	id, ok := n.AttributeString("id")
+	if !ok {
+		return ""
+	}
+	bs, ok := id.([]byte)
+	if !ok || !bytes.HasPrefix(bs, []byte(kind+":")) {
+		return ""
+	}
+	return string(bs)
+}
+
+// labelID returns the id source of a label, "fig:name" to "fig-name".
+func labelID(label string) []byte {
+	return []byte(strings.Replace(label, ":", "-", 1))
+}
+
+// parseInfoAttributes parses the trailing "{...}" of a fenced code info string.
+func parseInfoAttributes(fc *ast.FencedCodeBlock, src []byte) parser.Attributes {
+	if fc.Info == nil {
+		return nil
+	}
+	info := bytes.TrimSpace(fc.Info.Segment.Value(src))
+	if len(info) == 0 || info[len(info)-1] != '}' {
+		return nil
+	}
+	i := bytes.IndexByte(info, '{')
+	if i < 0 {
+		return nil
+	}
+	attrs, ok := parser.ParseAttributes(text.NewReader(info[i:]))
+	if !ok {
+		return nil
+	}
+	return attrs
+}
+
+// figureImage returns the image of a paragraph consisting of a single image,
+// optionally followed by "{#fig:name}" attributes.
+func figureImage(p *ast.Paragraph, src []byte) (*ast.Image, parser.Attributes, bool) {
+	img, ok := p.FirstChild().(*ast.Image)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var rest bytes.Buffer
+	for c := img.NextSibling(); c != nil; c = c.NextSibling() {
+		tx, ok := c.(*ast.Text)
+		if !ok {
+			return nil, nil, false
+		}
+		rest.Write(tx.Value(src))
+	}
+	tail := bytes.TrimSpace(rest.Bytes())
+	if len(tail) == 0 {
+		return img, nil, true
+	}
+
+	r := text.NewReader(tail)
+	attrs, ok := parser.ParseAttributes(r)
+	if !ok {
+		return nil, nil, false
+	}
+	if _, seg := r.Position(); seg.Start != len(tail) {
+		return nil, nil, false
+	}
+	return img, attrs, true
+}
+
+type sectionCounter struct {
+	base   int
+	title  ast.Node
+	counts []int
+}
+
+func newSectionCounter(doc *ast.Document) *sectionCounter {
+	var first *ast.Heading
+	top := 7
+	tops := 0
+	for c := doc.FirstChild(); c != nil; c = c.NextSibling() {
+		h, ok := c.(*ast.Heading)
+		if !ok {
+			continue
+		}
+		if first == nil {
+			first = h
+		}
+		if h.Level < top {
+			top = h.Level
+			tops = 0
+		}
+		if h.Level == top {
+			tops++
+		}
+	}
+
+	sc := &sectionCounter{base: top}
+	if first != nil && first.Level == top && tops == 1 {
+		sc.title = first
+		sc.base = top + 1
+	}
+	return sc
+}
+
+func (sc *sectionCounter) next(h *ast.Heading) string {
+	if h == sc.title || h.Level < sc.base {
+		return ""
+	}
+	d := h.Level - sc.base
+	for len(sc.counts) <= d {
+		sc.counts = append(sc.counts, 0)
+	}
+	sc.counts = sc.counts[:d+1]
+	sc.counts[d]++
+
+	num := make([]string, len(sc.counts))
+	for i, c := range sc.counts {
+		num[i] = strconv.Itoa(c)
+	}
+	return strings.Join(num, ".")
+}
+
+func (t *xrefTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	src := reader.Source()
+
+	targets := map[string]*target{}
+	add := func(n ast.Node, label string, id []byte, display string) {
+		if label == "" {
+			return
+		}
+		if _, ok := targets[label]; ok {
+			t.report(src, nodePos(n), "duplicate cross reference label: "+label)
+			return
+		}
+		targets[label] = &target{id: id, display: display}
+	}
+
+	sections := newSectionCounter(doc)
+	figures, tables, listings := 0, 0, 0
+	refs := []*XrefNode{}
+	edits := []func(){}
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *XrefNode:
+			refs = append(refs, n)
+		case *ast.Heading:
+			num := sections.next(n)
+			display := plainText(n, src)
+			if num != "" && t.NumberSections {
+				display = t.Names.Section + " " + num
+				edits = append(edits, func() {
+					n.InsertBefore(n, n.FirstChild(), NewNumberNode(num))
+				})
+			}
+			id, _ := n.AttributeString("id")
+			bs, _ := id.([]byte)
+			add(n, labelOf(n, "sec"), bs, display)
+		case *dtast.Table:
+			cap := n.Caption()
+			if cap == nil {
+				return ast.WalkContinue, nil
+			}
+			tables++
+			num := t.Names.Table + " " + strconv.Itoa(tables)
+			edits = append(edits, func() {
+				cap.InsertBefore(cap, cap.FirstChild(), NewNumberNode(num+":"))
+			})
+			id, _ := n.AttributeString("id")
+			bs, _ := id.([]byte)
+			add(n, labelOf(n, "tbl"), bs, num)
+		case *ast.Paragraph:
+			img, attrs, ok := figureImage(n, src)
+			if !ok {
+				return ast.WalkContinue, nil
+			}
+			caption := plainText(img, src)
+			label := ""
+			for _, a := range attrs {
+				if v, ok := a.Value.([]byte); ok && string(a.Name) == "id" &&
+					bytes.HasPrefix(v, []byte("fig:")) {
+					label = string(v)
+				}
+			}
+			if caption == "" && label == "" {
+				return ast.WalkContinue, nil
+			}
+
+			figures++
+			num := t.Names.Figure + " " + strconv.Itoa(figures)
+			fig := NewFigureNode("xref-figure", num, caption)
+			if label != "" {
+				id := pc.IDs().Generate(labelID(label), KindFigure)
+				fig.SetAttributeString("id", id)
+				add(n, label, id, num)
+			}
+			edits = append(edits, func() {
+				fig.AppendChild(fig, img)
+				n.Parent().ReplaceChild(n.Parent(), n, fig)
+			})
+			return ast.WalkSkipChildren, nil
+		case *ast.FencedCodeBlock:
+			label, caption := "", ""
+			for _, a := range parseInfoAttributes(n, src) {
+				v, ok := a.Value.([]byte)
+				if !ok {
+					continue
+				}
+				switch string(a.Name) {
+				case "id":
+					if bytes.HasPrefix(v, []byte("lst:")) {
+						label = string(v)
+					}
+				case "caption":
+					caption = string(v)
+				}
+			}
+			if caption == "" && label == "" {
+				return ast.WalkContinue, nil
+			}
+
+			listings++
+			num := t.Names.Listing + " " + strconv.Itoa(listings)
+			lst := NewFigureNode("xref-listing", num, caption)
+			if label != "" {
+				id := pc.IDs().Generate(labelID(label), KindFigure)
+				lst.SetAttributeString("id", id)
+				add(n, label, id, num)
+			}
+			edits = append(edits, func() {
+				n.Parent().ReplaceChild(n.Parent(), n, lst)
+				lst.AppendChild(lst, n)
+			})
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, edit := range edits {
+		edit()
+	}
+
+	for _, ref := range refs {
+		tg, ok := targets[ref.Label]
+		if !ok || tg.id == nil {
+			t.report(src, ref.Pos(), "unresolved cross reference: "+ref.Label)
+			continue
+		}
+		ref.Target = string(tg.id)
+		ref.Display = tg.display
+	}
+}
@@ -0,0 +1,110 @@
+package xref
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+	"github.com/1f408/cats_eeds/md2html/dt_table"
+)
+
+func TestXref(t *testing.T) {
+	diags := []*diag.Diagnostic{}
+	markdown := goldmark.New(
+		goldmark.WithExtensions(
+			dt_table.Table,
+			NewXref(
+				WithNumberSections(true),
+				WithReport(func(d *diag.Diagnostic) { diags = append(diags, d) }),
+			),
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithAttribute(),
+		),
+	)
+
+	src := []byte(`# Title
+
+## Intro {#sec:intro}
+
+See [@fig:cat], [@tbl:nums], [@lst:hello] and [@sec:intro].
+Missing [@fig:dog].
+
+![A cat](cat.png) {#fig:cat}
+
+Table: Numbers {#tbl:nums}
+| a |
+|---|
+| 1 |
+
+` + "```go {#lst:hello caption=\"Hello\"}\npackage main\n```\n")
+	var buf bytes.Buffer
+	if err := markdown.Convert(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<h1 id="title">Title</h1>
+<h2 id="sec:intro"><span class="xref-number">1</span> Intro</h2>
+<p>See <a class="xref" href="#fig-cat">Figure 1</a>, <a class="xref" href="#tbl:nums">Table 1</a>, <a class="xref" href="#lst-hello">Listing 1</a> and <a class="xref" href="#sec:intro">Section 1</a>.
+Missing <span class="xref-unresolved">[@fig:dog]</span>.</p>
+<figure class="xref-figure" id="fig-cat">
+<img src="cat.png" alt="A cat">
+<figcaption><span class="xref-number">Figure 1:</span> A cat</figcaption>
+</figure>
+<table id="tbl:nums">
+<caption><span class="xref-number">Table 1:</span> Numbers</caption>
+<thead>
+<tr>
+<th>a</th>
+</tr>
+</thead>
+<tbody>
+<tr>
+<td>1</td>
+</tr>
+</tbody>
+</table>
+<figure class="xref-listing" id="lst-hello">
+<pre><code class="language-go">package main
+</code></pre>
+<figcaption><span class="xref-number">Listing 1:</span> Hello</figcaption>
+</figure>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(diags) != 1 || diags[0].Line != 6 || diags[0].Column != 9 {
+		t.Errorf("got diagnostics %v", diags)
+	}
+}
+
+func TestSectionNumbers(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithExtensions(NewXref(
+			WithNames(Names{Section: "Sec."}),
+		)),
+		goldmark.WithParserOptions(parser.WithAttribute()),
+	)
+
+	src := []byte("# A\n\n## A.1\n\n# B {#sec:b}\n\n## B.1 {#sec:b1}\n\n### B.1.1 {#sec:b11}\n\n" +
+		"[@sec:b] [@sec:b1] [@sec:b11]\n")
+	var buf bytes.Buffer
+	if err := markdown.Convert(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<h1>A</h1>
+<h2>A.1</h2>
+<h1 id="sec:b">B</h1>
+<h2 id="sec:b1">B.1</h2>
+<h3 id="sec:b11">B.1.1</h3>
+<p><a class="xref" href="#sec:b">B</a> <a class="xref" href="#sec:b1">B.1</a> <a class="xref" href="#sec:b11">B.1.1</a></p>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
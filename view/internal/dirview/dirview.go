@@ -2,15 +2,18 @@ package dirview
 
 import (
 	"io/fs"
+	"os"
 	"path"
 	"regexp"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/l4go/rpath"
 	"github.com/l4go/unifs"
 	"github.com/lestrrat-go/strftime"
 
+	"github.com/1f408/cats_eeds/internal/ftype"
 	"github.com/1f408/cats_eeds/internal/perenc"
 	"github.com/1f408/cats_eeds/upath"
 )
@@ -19,6 +22,35 @@ type FileStamp struct {
 	Name  string
 	Path  string
 	Stamp string
+	Tasks *TaskCount
+
+	task_file string
+}
+
+// TaskCount is the number of the task items of a markdown file.
+type TaskCount struct {
+	Total int
+	Done  int
+}
+
+func (tc TaskCount) Open() int {
+	return tc.Total - tc.Done
+}
+
+// Percent returns the done ratio in percent, rounded down.
+func (tc TaskCount) Percent() int {
+	if tc.Total == 0 {
+		return 0
+	}
+	return tc.Done * 100 / tc.Total
+}
+
+// TaskCountFunc counts the task items of a markdown file.
+type TaskCountFunc func(full_file string) (TaskCount, error)
+
+type taskStamp struct {
+	mod   time.Time
+	tasks *TaskCount
 }
 
 type pathInfo struct {
@@ -33,6 +65,10 @@ type DirViewStamp struct {
 	tf        *strftime.Strftime
 	hide      []*regexp.Regexp
 	path_hide []*regexp.Regexp
+
+	task_count TaskCountFunc
+	task_mtx   sync.Mutex
+	task_cache map[string]*taskStamp
 }
 
 var DefaultHidden []*regexp.Regexp = []*regexp.Regexp{
@@ -90,6 +126,13 @@ func (dvs *DirViewStamp) Get(dir_rpath string, use_cwd bool) []*FileStamp {
 			p := perenc.EncodeUrlPath(n)
 			ts := dvs.tf.FormatString(fi.Info.ModTime())
 			uniq[n] = &FileStamp{Name: n, Path: p, Stamp: ts}
+			if !fi.Info.IsDir() {
+				full_file := rpath.Join(root.String(), fi.Path)
+				if tasks, ok := dvs.countTasks(full_file, mod); ok {
+					uniq[n].Tasks = tasks
+					uniq[n].task_file = full_file
+				}
+			}
 		}
 	}
 
@@ -104,6 +147,55 @@ func (dvs *DirViewStamp) Get(dir_rpath string, use_cwd bool) []*FileStamp {
 	return fi_lst
 }
 
+// SetTaskCounter makes Get count the task items of the markdown files.
+func (dvs *DirViewStamp) SetTaskCounter(fn TaskCountFunc) {
+	dvs.task_mtx.Lock()
+	defer dvs.task_mtx.Unlock()
+
+	dvs.task_count = fn
+	dvs.task_cache = map[string]*taskStamp{}
+}
+
+// TaskFiles returns the markdown files whose task items are counted in lst.
+// A page showing lst depends on them.
+func TaskFiles(lst []*FileStamp) []string {
+	files := []string{}
+	for _, f := range lst {
+		if f.task_file != "" {
+			files = append(files, f.task_file)
+		}
+	}
+	return files
+}
+
+func (dvs *DirViewStamp) countTasks(full_file string, mod time.Time) (*TaskCount, bool) {
+	if kind, _ := ftype.GetFileKindByExt(rpath.Ext(full_file)); kind != "text/markdown" {
+		return nil, false
+	}
+
+	dvs.task_mtx.Lock()
+	count := dvs.task_count
+	ts, ok := dvs.task_cache[full_file]
+	dvs.task_mtx.Unlock()
+	if count == nil {
+		return nil, false
+	}
+	if ok && ts.mod.Equal(mod) {
+		return ts.tasks, true
+	}
+
+	var tasks *TaskCount
+	if tc, err := count(full_file); err == nil && tc.Total > 0 {
+		tasks = &tc
+	}
+
+	dvs.task_mtx.Lock()
+	dvs.task_cache[full_file] = &taskStamp{mod: mod, tasks: tasks}
+	dvs.task_mtx.Unlock()
+
+	return tasks, true
+}
+
 func (dvs *DirViewStamp) DirModTime(rel_dir string) (time.Time, bool) {
 	var found bool = false
 	var dir_mod time.Time
@@ -240,3 +332,61 @@ func name_less(slice []*FileStamp, i, j int) bool {
 	}
 	return false
 }
+
+type WalkFunc func(rel_name string, root upath.UPath, fi fs.FileInfo) error
+
+func (dvs *DirViewStamp) Walk(fn WalkFunc) error {
+	seen := map[string]struct{}{}
+	for _, root := range dvs.roots {
+		if err := dvs.walk_dir(root, "/", seen, nil, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walk_dir does not descend into a directory which is the same file as one
+// of its parents, so a symlink loop does not recurse forever.
+func (dvs *DirViewStamp) walk_dir(root upath.UPath, rel_dir string,
+	seen map[string]struct{}, parents []fs.FileInfo, fn WalkFunc) error {
+	fi_lst, err := dvs.get_files(root.String(), rel_dir)
+	if err != nil {
+		return err
+	}
+
+	for _, pi := range fi_lst {
+		if pi.Name == "../" {
+			continue
+		}
+
+		rel_name := pi.Path
+		if pi.Name == "./" {
+			rel_name = rpath.SetDir(rel_dir)
+			parents = append(parents, pi.Info)
+		}
+		if _, has := seen[rel_name]; !has {
+			seen[rel_name] = struct{}{}
+			if err := fn(rel_name, root, pi.Info); err != nil {
+				return err
+			}
+		}
+
+		if pi.Name != "./" && pi.Info.IsDir() && !isParent(parents, pi.Info) {
+			if err := dvs.walk_dir(root, rel_name, seen, parents, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func isParent(parents []fs.FileInfo, fi fs.FileInfo) bool {
+	for _, pfi := range parents {
+		if os.SameFile(pfi, fi) {
+			return true
+		}
+	}
+	return false
+}
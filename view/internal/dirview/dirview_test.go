@@ -0,0 +1,191 @@
+package dirview
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/1f408/cats_eeds/upath"
+)
+
+var errTestReadDir = errors.New("read dir error")
+
+type errDirFS struct {
+	fstest.MapFS
+	bad string
+}
+
+func (fsys errDirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == fsys.bad {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errTestReadDir}
+	}
+	return fsys.MapFS.ReadDir(name)
+}
+
+func newTestDirViewStamp(t *testing.T, bad string) *DirViewStamp {
+	t.Helper()
+	fsys := errDirFS{
+		MapFS: fstest.MapFS{
+			"doc/README.md":  {Data: []byte("# top\n")},
+			"doc/sub/a.md":   {Data: []byte("# a\n")},
+			"doc/sub/b.txt":  {Data: []byte("b\n")},
+			"doc/.hidden.md": {Data: []byte("# hidden\n")},
+		},
+		bad: bad,
+	}
+
+	dvs, err := NewDirViewStamp(fsys, []upath.UPath{upath.MustNew("/doc/")}, "%F %T", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dvs
+}
+
+func TestWalk(t *testing.T) {
+	dvs := newTestDirViewStamp(t, "")
+
+	names := []string{}
+	err := dvs.Walk(func(rel_name string, _ upath.UPath, _ fs.FileInfo) error {
+		names = append(names, rel_name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/", "/sub/", "/sub/a.md", "/sub/b.txt", "/README.md"}
+	slices.Sort(names)
+	slices.Sort(want)
+	if !slices.Equal(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestWalkReadError(t *testing.T) {
+	dvs := newTestDirViewStamp(t, "doc/sub")
+
+	err := dvs.Walk(func(string, upath.UPath, fs.FileInfo) error {
+		return nil
+	})
+	if !errors.Is(err, errTestReadDir) {
+		t.Errorf("got %v, want %v", err, errTestReadDir)
+	}
+}
+
+func TestWalkSymlinkLoop(t *testing.T) {
+	top := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(top, "doc", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(top, "doc", "sub", "a.md"), []byte("# a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..", filepath.Join(top, "doc", "sub", "loop")); err != nil {
+		t.Skip(err)
+	}
+
+	dvs, err := NewDirViewStamp(os.DirFS(top), []upath.UPath{upath.MustNew("/doc/")}, "%F %T", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	err = dvs.Walk(func(rel_name string, _ upath.UPath, _ fs.FileInfo) error {
+		names = append(names, rel_name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/", "/sub/", "/sub/a.md", "/sub/loop/"}
+	slices.Sort(names)
+	if !slices.Equal(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestTaskCount(t *testing.T) {
+	mod := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"doc/todo.md":    {Data: []byte("- [x] a\n"), ModTime: mod},
+		"doc/none.md":    {Data: []byte("# none\n"), ModTime: mod},
+		"doc/bad.md":     {Data: []byte("bad\n"), ModTime: mod},
+		"doc/b.txt":      {Data: []byte("- [ ] b\n"), ModTime: mod},
+		"doc/sub/c.md":   {Data: []byte("- [ ] c\n"), ModTime: mod},
+		"doc/.hidden.md": {Data: []byte("- [ ] h\n"), ModTime: mod},
+	}
+	dvs, err := NewDirViewStamp(fsys, []upath.UPath{upath.MustNew("/doc/")}, "%F %T", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if files := TaskFiles(dvs.Get("/", false)); len(files) != 0 {
+		t.Errorf("no counter: got %v", files)
+	}
+
+	counts := map[string]TaskCount{
+		"/doc/todo.md": {Total: 4, Done: 1},
+	}
+	calls := map[string]int{}
+	dvs.SetTaskCounter(func(full_file string) (TaskCount, error) {
+		calls[full_file]++
+		if full_file == "/doc/bad.md" {
+			return TaskCount{}, errTestReadDir
+		}
+		return counts[full_file], nil
+	})
+
+	get := func() map[string]*TaskCount {
+		lst := dvs.Get("/", false)
+		files := TaskFiles(lst)
+		slices.Sort(files)
+		if want := []string{"/doc/bad.md", "/doc/none.md", "/doc/todo.md"}; !slices.Equal(files, want) {
+			t.Errorf("task files: got %v, want %v", files, want)
+		}
+
+		tasks := map[string]*TaskCount{}
+		for _, f := range lst {
+			tasks[f.Name] = f.Tasks
+		}
+		return tasks
+	}
+
+	tasks := get()
+	if tc := tasks["todo.md"]; tc == nil || *tc != counts["/doc/todo.md"] {
+		t.Errorf("todo.md: got %v", tc)
+	} else if tc.Open() != 3 || tc.Percent() != 25 {
+		t.Errorf("todo.md: open %d, percent %d", tc.Open(), tc.Percent())
+	}
+	for _, name := range []string{"none.md", "bad.md", "b.txt", "sub/"} {
+		if tc := tasks[name]; tc != nil {
+			t.Errorf("%s: got %v", name, tc)
+		}
+	}
+
+	get()
+	for _, name := range []string{"/doc/todo.md", "/doc/none.md", "/doc/bad.md"} {
+		if calls[name] != 1 {
+			t.Errorf("%s is counted %d times", name, calls[name])
+		}
+	}
+
+	counts["/doc/todo.md"] = TaskCount{Total: 3, Done: 2}
+	fsys["doc/todo.md"].ModTime = mod.Add(time.Minute)
+	tasks = get()
+	if tc := tasks["todo.md"]; tc == nil || *tc != counts["/doc/todo.md"] || tc.Percent() != 66 {
+		t.Errorf("modified todo.md: got %v", tc)
+	}
+	if calls["/doc/todo.md"] != 2 || calls["/doc/none.md"] != 1 {
+		t.Errorf("recount: got %v", calls)
+	}
+
+	if p := (TaskCount{}).Percent(); p != 0 {
+		t.Errorf("empty percent: got %d", p)
+	}
+}
@@ -0,0 +1,321 @@
+package pcache
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/l4go/unifs"
+)
+
+type Dep struct {
+	Path    string
+	ModTime time.Time
+}
+
+type Entry struct {
+	Name        string
+	Tag         string
+	ContentType string
+	LastMod     string
+	Body        []byte
+	Deps        []Dep
+}
+
+// The dependency lists and the disk files are kept for more entries than
+// the bodies in memory, but they are bounded as well.
+const depsScale = 8
+const diskScale = 16
+
+type depsItem struct {
+	name string
+	deps []Dep
+}
+
+type Cache struct {
+	mtx      sync.Mutex
+	fsys     fs.FS
+	max      int
+	disk_dir string
+	disk_max int
+	disk_cnt int
+
+	lru   *list.List
+	items map[string]*list.Element
+
+	deps_lru   *list.List
+	deps_items map[string]*list.Element
+}
+
+func New(fsys fs.FS, max int, disk_dir string) *Cache {
+	return &Cache{
+		fsys:       fsys,
+		max:        max,
+		disk_dir:   disk_dir,
+		disk_max:   max * diskScale,
+		disk_cnt:   max * diskScale, // the first save counts the files on the disk.
+		lru:        list.New(),
+		items:      map[string]*list.Element{},
+		deps_lru:   list.New(),
+		deps_items: map[string]*list.Element{},
+	}
+}
+
+func NewDeps(fsys fs.FS, files []string) []Dep {
+	deps := make([]Dep, 0, len(files))
+	for _, f := range files {
+		fi, err := unifs.Stat(fsys, f)
+		if err != nil {
+			continue
+		}
+		deps = append(deps, Dep{Path: f, ModTime: fi.ModTime()})
+	}
+
+	return deps
+}
+
+func LatestModTime(deps []Dep) time.Time {
+	var mod time.Time
+	for _, d := range deps {
+		if d.ModTime.After(mod) {
+			mod = d.ModTime
+		}
+	}
+	return mod
+}
+
+func (c *Cache) isFresh(ent *Entry) bool {
+	for _, d := range ent.Deps {
+		fi, err := unifs.Stat(c.fsys, d.Path)
+		if err != nil || !fi.ModTime().Equal(d.ModTime) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cache) diskName(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(c.disk_dir, hex.EncodeToString(sum[:]))
+}
+
+func (c *Cache) loadDisk(name string) *Entry {
+	if c.disk_dir == "" {
+		return nil
+	}
+
+	bin, err := os.ReadFile(c.diskName(name))
+	if err != nil {
+		return nil
+	}
+
+	ent := &Entry{}
+	if err := gob.NewDecoder(bytes.NewReader(bin)).Decode(ent); err != nil {
+		return nil
+	}
+	if ent.Name != name {
+		return nil
+	}
+
+	now := time.Now()
+	os.Chtimes(c.diskName(name), now, now)
+	return ent
+}
+
+func (c *Cache) saveDisk(ent *Entry) {
+	if c.disk_dir == "" {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ent); err != nil {
+		return
+	}
+
+	f, err := os.CreateTemp(c.disk_dir, ".pcache-*")
+	if err != nil {
+		return
+	}
+	tmp := f.Name()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	if err := os.Rename(tmp, c.diskName(ent.Name)); err != nil {
+		os.Remove(tmp)
+		return
+	}
+
+	c.disk_cnt++
+	if c.disk_max > 0 && c.disk_cnt > c.disk_max {
+		c.pruneDisk()
+	}
+}
+
+// pruneDisk removes the least recently used files over the disk limit.
+func (c *Cache) pruneDisk() {
+	des, err := os.ReadDir(c.disk_dir)
+	if err != nil {
+		return
+	}
+
+	type diskFile struct {
+		name string
+		mod  time.Time
+	}
+	files := []diskFile{}
+	for _, de := range des {
+		if !de.Type().IsRegular() || strings.HasPrefix(de.Name(), ".") {
+			continue
+		}
+		fi, err := de.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, diskFile{name: de.Name(), mod: fi.ModTime()})
+	}
+
+	c.disk_cnt = len(files)
+	if len(files) <= c.disk_max {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].mod.Before(files[j].mod)
+	})
+	for _, f := range files[:len(files)-c.disk_max] {
+		if os.Remove(filepath.Join(c.disk_dir, f.name)) == nil {
+			c.disk_cnt--
+		}
+	}
+}
+
+func (c *Cache) removeDisk(name string) {
+	if c.disk_dir == "" {
+		return
+	}
+	os.Remove(c.diskName(name))
+}
+
+func (c *Cache) lookup(name string) *Entry {
+	if elm, ok := c.items[name]; ok {
+		c.lru.MoveToFront(elm)
+		return elm.Value.(*Entry)
+	}
+
+	ent := c.loadDisk(name)
+	if ent == nil {
+		return nil
+	}
+	c.insert(ent)
+	return ent
+}
+
+// insert adds ent to the LRU list.
+// The dependencies are kept after the eviction of the body, so that the
+// ETag of the entry does not change.
+func (c *Cache) insert(ent *Entry) {
+	c.insertDeps(ent.Name, ent.Deps)
+	if elm, ok := c.items[ent.Name]; ok {
+		elm.Value = ent
+		c.lru.MoveToFront(elm)
+		return
+	}
+
+	c.items[ent.Name] = c.lru.PushFront(ent)
+	for c.max > 0 && c.lru.Len() > c.max {
+		last := c.lru.Back()
+		c.lru.Remove(last)
+		delete(c.items, last.Value.(*Entry).Name)
+	}
+}
+
+func (c *Cache) insertDeps(name string, deps []Dep) {
+	if elm, ok := c.deps_items[name]; ok {
+		elm.Value.(*depsItem).deps = deps
+		c.deps_lru.MoveToFront(elm)
+		return
+	}
+
+	c.deps_items[name] = c.deps_lru.PushFront(&depsItem{name: name, deps: deps})
+	for c.max > 0 && c.deps_lru.Len() > c.max*depsScale {
+		last := c.deps_lru.Back()
+		c.deps_lru.Remove(last)
+		delete(c.deps_items, last.Value.(*depsItem).name)
+	}
+}
+
+func (c *Cache) lookupDeps(name string) []Dep {
+	if elm, ok := c.deps_items[name]; ok {
+		c.deps_lru.MoveToFront(elm)
+		return elm.Value.(*depsItem).deps
+	}
+
+	if ent := c.lookup(name); ent != nil {
+		return ent.Deps
+	}
+	return nil
+}
+
+func (c *Cache) remove(name string) {
+	if elm, ok := c.items[name]; ok {
+		c.lru.Remove(elm)
+		delete(c.items, name)
+	}
+	c.removeDisk(name)
+}
+
+func (c *Cache) DepsModTime(name string) time.Time {
+	c.mtx.Lock()
+	deps := c.lookupDeps(name)
+	c.mtx.Unlock()
+
+	var mod time.Time
+	for _, d := range deps {
+		fi, err := unifs.Stat(c.fsys, d.Path)
+		if err != nil {
+			continue
+		}
+		if m := fi.ModTime(); m.After(mod) {
+			mod = m
+		}
+	}
+	return mod
+}
+
+func (c *Cache) Get(name string, tag string) (*Entry, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	ent := c.lookup(name)
+	if ent == nil {
+		return nil, false
+	}
+	if ent.Tag != tag || !c.isFresh(ent) {
+		c.remove(name)
+		return nil, false
+	}
+
+	return ent, true
+}
+
+func (c *Cache) Put(ent *Entry) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.insert(ent)
+	c.saveDisk(ent)
+}
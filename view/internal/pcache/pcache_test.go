@@ -0,0 +1,149 @@
+package pcache
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		"doc/a.md":   {Data: []byte("a"), ModTime: testTime},
+		"doc/inc.md": {Data: []byte("inc"), ModTime: testTime.Add(time.Hour)},
+	}
+}
+
+func newTestEntry(fsys fstest.MapFS, name string) *Entry {
+	return &Entry{
+		Name:        name,
+		Tag:         "tag-" + name,
+		ContentType: "text/html",
+		Body:        []byte("body of " + name),
+		Deps:        NewDeps(fsys, []string{"/doc/a.md", "/doc/inc.md", "/doc/none.md"}),
+	}
+}
+
+func TestLRU(t *testing.T) {
+	fsys := newTestFS()
+	c := New(fsys, 2, "")
+
+	c.Put(newTestEntry(fsys, "/a"))
+	c.Put(newTestEntry(fsys, "/b"))
+	if _, ok := c.Get("/a", "tag-/a"); !ok {
+		t.Fatal("/a is not cached")
+	}
+	c.Put(newTestEntry(fsys, "/c"))
+
+	if _, ok := c.Get("/b", "tag-/b"); ok {
+		t.Error("/b is not evicted")
+	}
+	for _, name := range []string{"/a", "/c"} {
+		if _, ok := c.Get(name, "tag-"+name); !ok {
+			t.Errorf("%s is evicted", name)
+		}
+	}
+	if _, ok := c.Get("/a", "other"); ok {
+		t.Error("/a matches other tag")
+	}
+	if _, ok := c.Get("/a", "tag-/a"); ok {
+		t.Error("/a is not removed on the tag mismatch")
+	}
+}
+
+func TestDisk(t *testing.T) {
+	fsys := newTestFS()
+	dir := t.TempDir()
+
+	want := newTestEntry(fsys, "/a")
+	New(fsys, 1, dir).Put(want)
+
+	c := New(fsys, 1, dir)
+	got, ok := c.Get("/a", want.Tag)
+	if !ok {
+		t.Fatal("/a is not loaded from the disk")
+	}
+	if got.Name != want.Name || got.ContentType != want.ContentType ||
+		!bytes.Equal(got.Body, want.Body) || len(got.Deps) != 2 {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	for i, d := range got.Deps {
+		if d.Path != want.Deps[i].Path || !d.ModTime.Equal(want.Deps[i].ModTime) {
+			t.Errorf("dep %d: got %+v, want %+v", i, d, want.Deps[i])
+		}
+	}
+
+	if _, ok := c.Get("/b", "tag-/b"); ok {
+		t.Error("/b is found")
+	}
+}
+
+func TestDepsModTime(t *testing.T) {
+	fsys := newTestFS()
+	c := New(fsys, 1, "")
+
+	if mod := c.DepsModTime("/a"); !mod.IsZero() {
+		t.Errorf("unknown entry: got %v", mod)
+	}
+
+	c.Put(newTestEntry(fsys, "/a"))
+	if mod := c.DepsModTime("/a"); !mod.Equal(testTime.Add(time.Hour)) {
+		t.Errorf("got %v", mod)
+	}
+
+	c.Put(newTestEntry(fsys, "/b"))
+	if _, ok := c.Get("/a", "tag-/a"); ok {
+		t.Fatal("/a is not evicted")
+	}
+	if mod := c.DepsModTime("/a"); !mod.Equal(testTime.Add(time.Hour)) {
+		t.Errorf("evicted entry: got %v", mod)
+	}
+
+	changed := testTime.Add(2 * time.Hour)
+	fsys["doc/inc.md"].ModTime = changed
+	if mod := c.DepsModTime("/b"); !mod.Equal(changed) {
+		t.Errorf("changed dependency: got %v", mod)
+	}
+	if _, ok := c.Get("/b", "tag-/b"); ok {
+		t.Error("/b is fresh after the dependency is changed")
+	}
+}
+
+func TestDepsBound(t *testing.T) {
+	fsys := newTestFS()
+	c := New(fsys, 1, "")
+
+	for i := 0; i <= depsScale; i++ {
+		c.Put(newTestEntry(fsys, "/"+strconv.Itoa(i)))
+	}
+	if n := c.deps_lru.Len(); n != depsScale {
+		t.Errorf("dependency lists: got %d, want %d", n, depsScale)
+	}
+	if mod := c.DepsModTime("/0"); !mod.IsZero() {
+		t.Errorf("evicted dependency list: got %v", mod)
+	}
+	if mod := c.DepsModTime("/1"); !mod.Equal(testTime.Add(time.Hour)) {
+		t.Errorf("kept dependency list: got %v", mod)
+	}
+}
+
+func TestDiskBound(t *testing.T) {
+	fsys := newTestFS()
+	dir := t.TempDir()
+	c := New(fsys, 1, dir)
+
+	for i := 0; i < diskScale+3; i++ {
+		c.Put(newTestEntry(fsys, "/"+strconv.Itoa(i)))
+	}
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(des) != diskScale {
+		t.Errorf("disk files: got %d, want %d", len(des), diskScale)
+	}
+}
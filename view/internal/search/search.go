@@ -0,0 +1,290 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+type Document struct {
+	Path    string
+	Title   string
+	Text    string
+	ModTime time.Time
+}
+
+type Hit struct {
+	Path    string `json:"path"`
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+	Score   int    `json:"score"`
+}
+
+type Index struct {
+	mtx      sync.RWMutex
+	docs     map[string]*Document
+	postings map[string]map[string]int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     map[string]*Document{},
+		postings: map[string]map[string]int{},
+	}
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r == 'ー'
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// flushCJK appends the unigrams and the bigrams of run, so that a single
+// character query also matches a longer run.
+func flushCJK(run []rune, tokens []string) []string {
+	for i := range run {
+		tokens = append(tokens, string(run[i]))
+		if i+1 < len(run) {
+			tokens = append(tokens, string(run[i:i+2]))
+		}
+	}
+	return tokens
+}
+
+func Tokenize(text string) []string {
+	tokens := []string{}
+	var word strings.Builder
+	cjk := []rune{}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			if word.Len() > 0 {
+				tokens = append(tokens, word.String())
+				word.Reset()
+			}
+			cjk = append(cjk, r)
+		case isWordRune(r):
+			tokens = flushCJK(cjk, tokens)
+			cjk = cjk[:0]
+			word.WriteRune(r)
+		default:
+			tokens = flushCJK(cjk, tokens)
+			cjk = cjk[:0]
+			if word.Len() > 0 {
+				tokens = append(tokens, word.String())
+				word.Reset()
+			}
+		}
+	}
+	tokens = flushCJK(cjk, tokens)
+	if word.Len() > 0 {
+		tokens = append(tokens, word.String())
+	}
+
+	return tokens
+}
+
+func (idx *Index) remove(path string) {
+	doc, ok := idx.docs[path]
+	if !ok {
+		return
+	}
+	delete(idx.docs, path)
+
+	for _, tok := range Tokenize(doc.Title + "\n" + doc.Text) {
+		if pl, ok := idx.postings[tok]; ok {
+			delete(pl, path)
+			if len(pl) == 0 {
+				delete(idx.postings, tok)
+			}
+		}
+	}
+}
+
+func (idx *Index) Put(doc *Document) {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+
+	idx.remove(doc.Path)
+	idx.docs[doc.Path] = doc
+	for _, tok := range Tokenize(doc.Title + "\n" + doc.Text) {
+		pl, ok := idx.postings[tok]
+		if !ok {
+			pl = map[string]int{}
+			idx.postings[tok] = pl
+		}
+		pl[doc.Path]++
+	}
+}
+
+func (idx *Index) Remove(path string) {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+
+	idx.remove(path)
+}
+
+func (idx *Index) ModTime(path string) (time.Time, bool) {
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+
+	doc, ok := idx.docs[path]
+	if !ok {
+		return time.Time{}, false
+	}
+	return doc.ModTime, true
+}
+
+func (idx *Index) Paths() []string {
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+
+	lst := make([]string, 0, len(idx.docs))
+	for p := range idx.docs {
+		lst = append(lst, p)
+	}
+	return lst
+}
+
+func queryWords(query string) []string {
+	words := []string{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !isCJK(r) && !isWordRune(r)
+	}) {
+		words = append(words, w)
+	}
+	return words
+}
+
+func (idx *Index) Search(query string, limit int) []*Hit {
+	words := queryWords(query)
+	if len(words) == 0 {
+		return []*Hit{}
+	}
+
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+
+	var cand map[string]int
+	for _, tok := range Tokenize(query) {
+		pl := idx.postings[tok]
+		next := map[string]int{}
+		for p, n := range pl {
+			if cand == nil {
+				next[p] = n
+			} else if sc, ok := cand[p]; ok {
+				next[p] = sc + n
+			}
+		}
+		cand = next
+		if len(cand) == 0 {
+			return []*Hit{}
+		}
+	}
+
+	hits := []*Hit{}
+	for p, sc := range cand {
+		doc := idx.docs[p]
+		lower := strings.ToLower(doc.Title + "\n" + doc.Text)
+		matched := true
+		for _, w := range words {
+			if !strings.Contains(lower, w) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		hits = append(hits, &Hit{
+			Path:    doc.Path,
+			Title:   doc.Title,
+			Snippet: Snippet(doc.Text, words),
+			Score:   sc,
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Path < hits[j].Path
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+const snippetWidth = 40
+
+func Snippet(text string, words []string) string {
+	rs := []rune(strings.Join(strings.Fields(text), " "))
+	lower := []rune(strings.ToLower(string(rs)))
+	if len(lower) != len(rs) {
+		lower = rs
+	}
+
+	match := make([]bool, len(rs))
+	first := -1
+	for _, w := range words {
+		wr := []rune(w)
+		for i := 0; i+len(wr) <= len(lower); i++ {
+			if string(lower[i:i+len(wr)]) != w {
+				continue
+			}
+			for j := i; j < i+len(wr); j++ {
+				match[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start := first - snippetWidth
+	if start < 0 {
+		start = 0
+	}
+	end := first + snippetWidth*2
+	if end > len(rs) {
+		end = len(rs)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	in_mark := false
+	for i := start; i < end; i++ {
+		if match[i] != in_mark {
+			if match[i] {
+				b.WriteString("<mark>")
+			} else {
+				b.WriteString("</mark>")
+			}
+			in_mark = match[i]
+		}
+		b.WriteString(html.EscapeString(string(rs[i])))
+	}
+	if in_mark {
+		b.WriteString("</mark>")
+	}
+	if end < len(rs) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World_1!", []string{"hello", "world_1"}},
+		{"日本語", []string{"日", "日本", "本", "本語", "語"}},
+		{"字", []string{"字"}},
+		{"Go言語とRust", []string{"go", "言", "言語", "語", "語と", "と", "rust"}},
+		{"カナー abc ひらが", []string{"カ", "カナ", "ナ", "ナー", "ー", "abc", "ひ", "ひら", "ら", "らが", "が"}},
+		{"한국어", []string{"한", "한국", "국", "국어", "어"}},
+		{"  ", []string{}},
+	}
+
+	for _, c := range cases {
+		if got := Tokenize(c.text); !slices.Equal(got, c.want) {
+			t.Errorf("%q: got %q, want %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	cases := []struct {
+		text  string
+		words []string
+		want  string
+	}{
+		{"foo bar baz", []string{"bar"}, "foo <mark>bar</mark> baz"},
+		{"Foo\n\tBAR  baz", []string{"bar"}, "Foo <mark>BAR</mark> baz"},
+		{"a <b> & c", []string{"b"}, "a &lt;<mark>b</mark>&gt; &amp; c"},
+		{"検索エンジンの検索", []string{"検索"}, "<mark>検索</mark>エンジンの<mark>検索</mark>"},
+		{"no match", []string{"zzz"}, "no match"},
+	}
+
+	for _, c := range cases {
+		if got := Snippet(c.text, c.words); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.text, got, c.want)
+		}
+	}
+
+	long := strings.Repeat("0123456789 ", 5) + "target " + strings.TrimSpace(strings.Repeat("0123456789 ", 8))
+	got := Snippet(long, []string{"target"})
+	want := "…456789 0123456789 0123456789 0123456789 <mark>target</mark> 0123456789 0123456789 0123456789 0123456789 0123456789 0123456789 0123456…"
+	if got != want {
+		t.Errorf("long: got %q, want %q", got, want)
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := NewIndex()
+	idx.Put(&Document{Path: "a.md", Title: "全文検索", Text: "The search index uses bigrams."})
+	idx.Put(&Document{Path: "b.md", Title: "Other", Text: "検索 and search and search."})
+	idx.Put(&Document{Path: "c.md", Title: "None", Text: "nothing here"})
+
+	hits := idx.Search("search", 0)
+	if len(hits) != 2 || hits[0].Path != "b.md" || hits[1].Path != "a.md" {
+		t.Fatalf("got %+v", hits)
+	}
+
+	hits = idx.Search("文検", 0)
+	if len(hits) != 1 || hits[0].Path != "a.md" {
+		t.Fatalf("got %+v", hits)
+	}
+
+	for _, q := range []string{"全", "文", "検 index"} {
+		if hits := idx.Search(q, 0); len(hits) != 1 || hits[0].Path != "a.md" {
+			t.Errorf("%q: got %+v", q, hits)
+		}
+	}
+
+	idx.Remove("b.md")
+	if hits := idx.Search("検索", 0); len(hits) != 1 || hits[0].Path != "a.md" {
+		t.Fatalf("after remove: got %+v", hits)
+	}
+}
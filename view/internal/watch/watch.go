@@ -0,0 +1,62 @@
+package watch
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+type Watcher struct {
+	ch    chan struct{}
+	done  chan struct{}
+	once  sync.Once
+	files map[string]struct{}
+	dirs  map[string]struct{}
+
+	sys sysWatcher
+}
+
+type sysWatcher interface {
+	close() error
+}
+
+func New(os_files []string) (*Watcher, error) {
+	w := &Watcher{
+		ch:    make(chan struct{}, 1),
+		done:  make(chan struct{}),
+		files: map[string]struct{}{},
+		dirs:  map[string]struct{}{},
+	}
+	for _, f := range os_files {
+		f = filepath.Clean(f)
+		w.files[f] = struct{}{}
+		w.dirs[filepath.Dir(f)] = struct{}{}
+	}
+
+	sys, err := newSysWatcher(w)
+	if err != nil {
+		return nil, err
+	}
+	w.sys = sys
+
+	return w, nil
+}
+
+func (w *Watcher) notify() {
+	select {
+	case w.ch <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) Changed() <-chan struct{} {
+	return w.ch
+}
+
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.sys.close()
+	})
+	return err
+}
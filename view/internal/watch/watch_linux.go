@@ -0,0 +1,98 @@
+//go:build linux
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO |
+	syscall.IN_MOVED_FROM | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_ATTRIB | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+type inotifyWatcher struct {
+	f   *os.File
+	wds map[int32]string
+}
+
+func newSysWatcher(w *Watcher) (sysWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	iw := &inotifyWatcher{
+		f:   os.NewFile(uintptr(fd), "inotify"),
+		wds: map[int32]string{},
+	}
+
+	for d := range w.dirs {
+		wd, err := syscall.InotifyAddWatch(fd, d, inotifyMask)
+		if err != nil {
+			continue
+		}
+		iw.wds[int32(wd)] = d
+	}
+	for f := range w.files {
+		if _, is_dir := w.dirs[f]; is_dir {
+			continue
+		}
+		if fi, err := os.Stat(f); err == nil && fi.IsDir() {
+			wd, err := syscall.InotifyAddWatch(fd, f, inotifyMask)
+			if err == nil {
+				iw.wds[int32(wd)] = f
+			}
+		}
+	}
+
+	if len(iw.wds) == 0 {
+		iw.f.Close()
+		return nil, syscall.ENOENT
+	}
+
+	go iw.run(w)
+	return iw, nil
+}
+
+func (iw *inotifyWatcher) run(w *Watcher) {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := iw.f.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name_bin := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+
+			dir, ok := iw.wds[ev.Wd]
+			if !ok {
+				continue
+			}
+			if _, watched := w.files[dir]; watched {
+				w.notify()
+				continue
+			}
+
+			name := string(name_bin)
+			for i := 0; i < len(name); i++ {
+				if name[i] == 0 {
+					name = name[:i]
+					break
+				}
+			}
+			if _, watched := w.files[filepath.Join(dir, name)]; watched {
+				w.notify()
+			}
+		}
+	}
+}
+
+func (iw *inotifyWatcher) close() error {
+	return iw.f.Close()
+}
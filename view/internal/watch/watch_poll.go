@@ -0,0 +1,62 @@
+//go:build !linux
+
+package watch
+
+import (
+	"os"
+	"time"
+)
+
+const pollInterval = time.Second
+
+type pollWatcher struct{}
+
+type fileStat struct {
+	exist bool
+	size  int64
+	mod   time.Time
+}
+
+func statFiles(w *Watcher) map[string]fileStat {
+	st := map[string]fileStat{}
+	for f := range w.files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			st[f] = fileStat{}
+			continue
+		}
+		st[f] = fileStat{exist: true, size: fi.Size(), mod: fi.ModTime()}
+	}
+	return st
+}
+
+func newSysWatcher(w *Watcher) (sysWatcher, error) {
+	go func() {
+		last := statFiles(w)
+		tc := time.NewTicker(pollInterval)
+		defer tc.Stop()
+
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-tc.C:
+			}
+
+			cur := statFiles(w)
+			for f, st := range cur {
+				if last[f] != st {
+					w.notify()
+					break
+				}
+			}
+			last = cur
+		}
+	}()
+
+	return &pollWatcher{}, nil
+}
+
+func (pw *pollWatcher) close() error {
+	return nil
+}
@@ -0,0 +1,70 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testWait = 3 * time.Second
+
+func writeTestFile(t *testing.T, name string, text string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.md")
+	other := filepath.Join(dir, "b.md")
+	writeTestFile(t, file, "a")
+	writeTestFile(t, other, "b")
+
+	w, err := New([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeTestFile(t, other, "b changed")
+	select {
+	case <-w.Changed():
+		t.Fatal("notified for the unwatched file")
+	case <-time.After(2 * time.Second):
+	}
+
+	writeTestFile(t, file, "a changed")
+	select {
+	case <-w.Changed():
+	case <-time.After(testWait):
+		t.Fatal("not notified for the watched file")
+	}
+}
+
+func TestWatcherCreate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "new.md")
+
+	w, err := New([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeTestFile(t, file, "new")
+	select {
+	case <-w.Changed():
+	case <-time.After(testWait):
+		t.Fatal("not notified for the created file")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second close: %v", err)
+	}
+}
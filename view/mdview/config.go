@@ -48,6 +48,13 @@ type MdViewConfig struct {
 
 	TextViewMode string `toml:",omitempty"`
 
+	SearchPath       string `toml:",omitempty"`
+	LiveReloadPath   string `toml:",omitempty"`
+	IncludeGraphPath string `toml:",omitempty"`
+
+	RenderCacheSize int         `toml:",omitempty"`
+	RenderCacheDir  upath.UPath `toml:",omitempty"`
+
 	SystemFS fs.FS     `toml:"-"`
 	ModTime  time.Time `toml:"-"`
 }
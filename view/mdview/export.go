@@ -0,0 +1,269 @@
+package mdview
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/l4go/rpath"
+	"github.com/l4go/unifs"
+	"golang.org/x/net/html"
+
+	"github.com/1f408/cats_eeds/internal/ftype"
+	"github.com/1f408/cats_eeds/upath"
+)
+
+type exportWrite struct {
+	buf  bytes.Buffer
+	code int
+	msg  string
+	file string
+}
+
+func (w *exportWrite) Header() Setter {
+	return &DummySetter{}
+}
+
+func (w *exportWrite) Write(buf []byte) (int, error) {
+	return w.buf.Write(buf)
+}
+
+func (w *exportWrite) WriteHeader(code int) {
+	w.code = code
+}
+
+func (w *exportWrite) Error(msg string, code int) {
+	w.code = code
+	w.msg = msg
+}
+
+func (w *exportWrite) ServeFile(fsys fs.FS, file string) {
+	w.file = file
+}
+
+type exportEntry struct {
+	req  string
+	out  string
+	root upath.UPath
+	page bool
+}
+
+func (mdv *MdView) exportOutName(rel_name string) (string, bool) {
+	if rpath.IsDir(rel_name) {
+		return rel_name + "index.html", true
+	}
+
+	kind, _ := ftype.GetFileKindByExt(rpath.Ext(rel_name))
+	if proc_type, _ := mdv.procType(kind); proc_type != "" {
+		return rel_name + ".html", true
+	}
+
+	return rel_name, false
+}
+
+func (mdv *MdView) Export(dst_dir string, eout io.Writer) error {
+	entries := []*exportEntry{}
+	out_tbl := map[string]string{}
+
+	werr := mdv.DirViewStamp.Walk(func(rel_name string, root upath.UPath, fi fs.FileInfo) error {
+		if !fi.IsDir() && !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		out, page := mdv.exportOutName(rel_name)
+		entries = append(entries, &exportEntry{
+			req: rel_name, out: out, root: root, page: page})
+		out_tbl[rel_name] = out
+		return nil
+	})
+	if werr != nil {
+		return new_err("export walk error: %v", werr)
+	}
+
+	fail := 0
+	for _, ent := range entries {
+		w := &exportWrite{}
+		mdv.writeView(ent.req, DummyGetter{}, w)
+
+		var err error
+		switch {
+		case w.code == http.StatusNotFound && ent.root != mdv.DocumentRoot:
+			if ent.page {
+				fmt.Fprintf(eout, "Skip: %s: not in document root\n", ent.req)
+				continue
+			}
+			err = mdv.exportCopy(dst_dir, ent, rpath.Join(ent.root.String(), ent.req))
+		case w.code != 0:
+			fmt.Fprintf(eout, "Error: %s: %d: %s\n", ent.req, w.code, w.msg)
+			fail++
+			continue
+		case w.file != "":
+			err = mdv.exportCopy(dst_dir, ent, w.file)
+		default:
+			var buf bytes.Buffer
+			if e := mdv.exportRewrite(&buf, w.buf.Bytes(), ent, out_tbl); e != nil {
+				fmt.Fprintf(eout, "Error: %s: html rewrite error: %s\n", ent.req, e)
+				fail++
+				continue
+			}
+			err = exportWriteFile(dst_dir, ent.out, buf.Bytes())
+		}
+		if err != nil {
+			return new_err("export write error: %s: %v", ent.out, err)
+		}
+	}
+
+	if fail > 0 {
+		return new_err("export failed: %d files", fail)
+	}
+	return nil
+}
+
+func exportOSPath(dst_dir string, out string) string {
+	return filepath.Join(dst_dir, filepath.FromSlash(strings.TrimPrefix(out, "/")))
+}
+
+func exportWriteFile(dst_dir string, out string, bin []byte) error {
+	name := exportOSPath(dst_dir, out)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, bin, 0644)
+}
+
+func (mdv *MdView) exportCopy(dst_dir string, ent *exportEntry, file string) error {
+	src, err := unifs.Open(mdv.SystemFS, file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	name := exportOSPath(dst_dir, ent.out)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+var exportLinkAttrs = map[string]struct{}{
+	"href":   {},
+	"src":    {},
+	"poster": {},
+}
+
+func (mdv *MdView) exportRewrite(w io.Writer, html_bin []byte,
+	ent *exportEntry, out_tbl map[string]string) error {
+	z := html.NewTokenizer(bytes.NewReader(html_bin))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return nil
+			}
+			return z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := append([]byte{}, z.Raw()...)
+			tok := z.Token()
+
+			changed := false
+			for i, a := range tok.Attr {
+				if _, ok := exportLinkAttrs[a.Key]; !ok || a.Namespace != "" {
+					continue
+				}
+				if v, ok := mdv.exportLink(a.Val, ent, out_tbl); ok {
+					tok.Attr[i].Val = v
+					changed = true
+				}
+			}
+
+			if !changed {
+				if _, err := w.Write(raw); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := io.WriteString(w, tok.String()); err != nil {
+				return err
+			}
+		default:
+			if _, err := w.Write(z.Raw()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (mdv *MdView) exportLink(link string, ent *exportEntry,
+	out_tbl map[string]string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+
+	var tgt string
+	if u.Path[0] == '/' {
+		if !strings.HasPrefix(u.Path, mdv.UrlTopPath) {
+			return "", false
+		}
+		tgt = rpath.Clean("/" + strings.TrimPrefix(u.Path, mdv.UrlTopPath))
+	} else {
+		tgt = rpath.Join(rpath.Dir(ent.req), u.Path)
+		if rpath.IsDir(u.Path) || u.Path == "." || u.Path == ".." {
+			tgt = rpath.SetDir(tgt)
+		}
+	}
+
+	out, ok := out_tbl[tgt]
+	if !ok {
+		if out, ok = out_tbl[rpath.SetDir(tgt)]; !ok {
+			return "", false
+		}
+	}
+
+	rel := exportRelPath(rpath.Dir(ent.out), out)
+	if i := strings.IndexAny(rel, ":/"); i >= 0 && rel[i] == ':' {
+		rel = "./" + rel
+	}
+
+	u.Path = rel
+	u.RawPath = ""
+	return u.String(), true
+}
+
+func exportRelPath(from_dir string, to string) string {
+	from := strings.Split(strings.Trim(from_dir, "/"), "/")
+	if from_dir == "/" {
+		from = []string{}
+	}
+	to_lst := strings.Split(strings.TrimPrefix(to, "/"), "/")
+
+	n := 0
+	for n < len(from) && n < len(to_lst)-1 && from[n] == to_lst[n] {
+		n++
+	}
+
+	rel := strings.Repeat("../", len(from)-n) + path.Join(to_lst[n:]...)
+	if rel == "" {
+		return "./"
+	}
+	return rel
+}
@@ -0,0 +1,152 @@
+package mdview
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/l4go/osfs"
+
+	"github.com/1f408/cats_eeds/internal/ftype"
+	"github.com/1f408/cats_eeds/md2html"
+	"github.com/1f408/cats_eeds/upath"
+	"github.com/1f408/cats_eeds/view/internal/dirview"
+)
+
+func newExportTestView(t *testing.T, files map[string]string) (*MdView, string) {
+	t.Helper()
+	if err := ftype.SetMarkdownExt("md"); err != nil {
+		t.Fatal(err)
+	}
+
+	top := t.TempDir()
+	for name, text := range files {
+		file := filepath.Join(top, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(top, "md.conf"), []byte{}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	md_cfg, err := md2html.NewMdConfig(osfs.OsRootFS, filepath.Join(top, "md.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mdv := newMdViewDefault()
+	mdv.SystemFS = osfs.OsRootFS
+	mdv.DocumentRoot = upath.MustNewByOS(filepath.Join(top, "doc"))
+	mdv.MarkdownConfig = md_cfg
+	mdv.CustomPageConfig = &md2html.CustomPageConfig{}
+	mdv.DirectoryViewMode = "none"
+	mdv.OriginTmpl = template.Must(template.New("mdview.tmpl").Parse(
+		`<html><body><a href="{{.Top}}">top</a>{{.Text}}</body></html>`))
+	mdv.DirViewStamp, err = dirview.NewDirViewStamp(mdv.SystemFS,
+		[]upath.UPath{mdv.DocumentRoot}, "%F %T", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mdv, filepath.Join(top, "out")
+}
+
+func readExport(t *testing.T, dst_dir string, name string) string {
+	t.Helper()
+	bin, err := os.ReadFile(filepath.Join(dst_dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bin)
+}
+
+func TestExport(t *testing.T) {
+	mdv, dst_dir := newExportTestView(t, map[string]string{
+		"doc/README.md":    "# Top\n\n[a](sub/a.md) [b](/sub/b.txt?x=1#l2) [ext](https://example.com/)\n\n![pic](pic.png)\n",
+		"doc/pic.png":      "png",
+		"doc/sub/a.md":     "# A\n\n[top](../README.md) [dir](./) [up](../)\n",
+		"doc/sub/b.txt":    "b\n",
+		"doc/sub/.hide.md": "# hidden\n",
+	})
+
+	var eout strings.Builder
+	if err := mdv.Export(dst_dir, &eout); err != nil {
+		t.Fatalf("%v: %s", err, eout.String())
+	}
+
+	if got := readExport(t, dst_dir, "pic.png"); got != "png" {
+		t.Errorf("pic.png: got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dst_dir, "sub", ".hide.md.html")); !os.IsNotExist(err) {
+		t.Errorf("hidden file is exported: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		want []string
+	}{
+		{"README.md.html", []string{
+			`<a href="index.html">top</a>`,
+			`<a href="sub/a.md.html">a</a>`,
+			`<a href="sub/b.txt.html?x=1#l2">b</a>`,
+			`<a href="https://example.com/">ext</a>`,
+			`<img src="pic.png" alt="pic"`,
+		}},
+		{"sub/a.md.html", []string{
+			`<a href="../index.html">top</a>`,
+			`<a href="../README.md.html">top</a>`,
+			`<a href="index.html">dir</a>`,
+			`<a href="../index.html">up</a>`,
+		}},
+		{"index.html", []string{`<a href="index.html">top</a>`, `<h1 id="top">Top</h1>`}},
+		{"sub/index.html", []string{`<a href="../index.html">top</a>`}},
+		{"sub/b.txt.html", []string{"b\n"}},
+	}
+	for _, c := range cases {
+		got := readExport(t, dst_dir, c.name)
+		for _, w := range c.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: %q not found in %s", c.name, w, got)
+			}
+		}
+	}
+}
+
+func TestExportWalkError(t *testing.T) {
+	mdv, dst_dir := newExportTestView(t, map[string]string{
+		"doc/README.md": "# Top\n",
+	})
+	if err := os.RemoveAll(filepath.Join(filepath.Dir(dst_dir), "doc")); err != nil {
+		t.Fatal(err)
+	}
+
+	var eout strings.Builder
+	if err := mdv.Export(dst_dir, &eout); err == nil {
+		t.Error("got no error for the missing document root")
+	}
+}
+
+func TestExportRelPath(t *testing.T) {
+	cases := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{"/", "/index.html", "index.html"},
+		{"/", "/a/b.md.html", "a/b.md.html"},
+		{"/a/", "/a/b.md.html", "b.md.html"},
+		{"/a/", "/index.html", "../index.html"},
+		{"/a/b/", "/a/c/d.html", "../c/d.html"},
+	}
+	for _, c := range cases {
+		if got := exportRelPath(c.from, c.to); got != c.want {
+			t.Errorf("exportRelPath(%q, %q): got %q, want %q", c.from, c.to, got, c.want)
+		}
+	}
+}
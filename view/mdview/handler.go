@@ -22,11 +22,12 @@ import (
 	"github.com/1f408/cats_eeds/frontmatter"
 	"github.com/1f408/cats_eeds/internal/perenc"
 	"github.com/1f408/cats_eeds/md2html"
+	"github.com/1f408/cats_eeds/md2html/diag"
 	"github.com/1f408/cats_eeds/view/internal/dirview"
 	"github.com/1f408/cats_eeds/view/internal/etag"
 	"github.com/1f408/cats_eeds/view/internal/htpath"
 	"github.com/1f408/cats_eeds/view/internal/links"
-	"github.com/1f408/cats_eeds/view/internal/tmplext"
+	"github.com/1f408/cats_eeds/view/internal/pcache"
 )
 
 type tmplParam struct {
@@ -43,8 +44,11 @@ type tmplParam struct {
 	Text      string
 	TextType  string
 	Toc       string
+	TableList string
+	Tasks     md2html.TaskCount
 	Files     []*dirview.FileStamp
 	IsOpen    bool
+	Search    *tmplSearch
 
 	CustomParam md2html.CustomParam
 }
@@ -105,6 +109,18 @@ func (mdv *MdView) Handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req_path := rpath.Clean("/" + r.URL.Path)
+	if mdv.LiveReloadPath != "" && req_path == mdv.LiveReloadPath {
+		mdv.liveReload(w, r)
+		return
+	}
+	if mdv.IncludeGraphPath != "" && req_path == mdv.IncludeGraphPath {
+		mdv.writeIncludeGraph(r.URL.Query().Get("path"), NewHttpWriter(w, r))
+		return
+	}
+	if mdv.SearchIndex != nil && req_path == mdv.SearchPath {
+		mdv.writeSearch(r.URL.Query().Get("q"), r.Header, NewHttpWriter(w, r))
+		return
+	}
 	mdv.writeView(req_path, r.Header, NewHttpWriter(w, r))
 }
 
@@ -116,6 +132,68 @@ func (mdv *MdView) Dump(out, eout io.Writer, req_path string) {
 	mdv.writeView(req_path, h, w)
 }
 
+func (mdv *MdView) procType(kind string) (string, string) {
+	switch {
+	case kind == "text/markdown":
+		return "md", ""
+	case mdv.TextViewMode != "raw" && strings.HasPrefix(kind, "text/"):
+		return "text", "plaintext"
+	}
+
+	return "", ""
+}
+
+func (mdv *MdView) newMd2Html(full_doc string, fm_param *md2html.FrontMatterParam) *md2html.Md2Html {
+	m2h := md2html.NewMd2Html(&md2html.Md2HtmlConfig{
+		MdConfig:    mdv.MarkdownConfig,
+		SystemIds:   mdv.SystemHtmlIds,
+		SystemFS:    mdv.SystemFS,
+		FrontMatter: mdv.CustomPageConfig.FrontMatter,
+		StartMdFile: full_doc,
+
+		DocumentRoot: mdv.DocumentRoot.String(),
+		IncludeGraph: mdv.IncludeGraph,
+		TocParam:     fm_param.Toc,
+		LineOffset:   fm_param.LineOffset,
+	})
+
+	if fm_param.MarkdownConfig != "" {
+		name := fm_param.MarkdownConfig
+		if name[0] != '/' {
+			name = rpath.Join(rpath.Dir(full_doc), name)
+		}
+		if strings.HasPrefix(name, mdv.DocumentRoot.String()) {
+			if md_cfg, err := md2html.NewMdConfig(mdv.SystemFS, name); err == nil {
+				m2h = m2h.NewLocalSpec(md_cfg)
+			} else {
+				m2h.Report(&diag.Diagnostic{
+					Severity: diag.Warning,
+					File:     full_doc,
+					Message:  "markdown_config load error: " + err.Error(),
+				})
+			}
+		} else {
+			m2h.Report(&diag.Diagnostic{
+				Severity: diag.Warning,
+				File:     full_doc,
+				Message:  "markdown_config outside document root: " + fm_param.MarkdownConfig,
+			})
+		}
+	}
+
+	return m2h
+}
+
+func (mdv *MdView) countTasks(full_doc string) (dirview.TaskCount, error) {
+	md_bin, _, err := mdv.readMarkdown(full_doc)
+	if err != nil {
+		return dirview.TaskCount{}, err
+	}
+
+	tc := md2html.CountTasks(md_bin)
+	return dirview.TaskCount{Total: tc.Total, Done: tc.Done}, nil
+}
+
 func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 	w_header := w.Header()
 
@@ -150,18 +228,14 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 	is_dir := htreq.IsDir()
 	has_doc := htreq.HasDoc()
 
-	kind := htreq.Kind()
 	var proc_type = ""
 	var text_type = ""
-	switch {
-	case !has_doc && is_dir:
+	if !has_doc && is_dir {
 		proc_type = "dir"
-	case kind == "text/markdown":
-		proc_type = "md"
-	case mdv.TextViewMode != "raw" && strings.HasPrefix(kind, "text/"):
-		proc_type = "text"
-		text_type = "plaintext"
-	default:
+	} else {
+		proc_type, text_type = mdv.procType(htreq.Kind())
+	}
+	if proc_type == "" {
 		mdv.setCacheHeader(w_header)
 		w.ServeFile(mdv.SystemFS, htreq.FullDoc())
 		return
@@ -173,6 +247,13 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 	}
 	last_mod := htreq.LastMod()
 
+	cache_name := htreq.FullReq()
+	if mdv.RenderCache != nil {
+		if dep_mod := mdv.RenderCache.DepsModTime(cache_name); dep_mod.After(mod_time) {
+			mod_time = dep_mod
+		}
+	}
+
 	tag := mdv.MakeEtag(mod_time)
 	if !isModified(r_header, tag, mod_time) {
 		w_header.Set("Last-Modified", last_mod)
@@ -181,6 +262,17 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 		return
 	}
 
+	if mdv.RenderCache != nil {
+		if ent, ok := mdv.RenderCache.Get(cache_name, tag); ok {
+			w_header.Set("Content-Type", ent.ContentType)
+			w_header.Set("Last-Modified", ent.LastMod)
+			w_header.Set("Etag", ent.Tag)
+			mdv.setCacheHeader(w_header)
+			w.Write(ent.Body)
+			return
+		}
+	}
+
 	var raw_bin []byte
 	var fm_param *md2html.FrontMatterParam = &md2html.FrontMatterParam{}
 	if has_doc {
@@ -289,6 +381,9 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 	var doc_bin []byte
 	var title_bin []byte
 	var toc_bin []byte
+	var table_list_bin []byte
+	var tasks md2html.TaskCount
+	var inc_files []string
 	req_abs_path := rpath.Join(mdv.UrlTopPath, req_rpath)
 
 	with_title_param := false
@@ -320,34 +415,18 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 		doc_bin = raw_bin
 		toc_bin = []byte{}
 	case "md":
-		var cerr error
 		var md_title_bin []byte
 
-		m2h := md2html.NewMd2Html(&md2html.Md2HtmlConfig{
-			MdConfig:    mdv.MarkdownConfig,
-			SystemIds:   mdv.SystemHtmlIds,
-			SystemFS:    mdv.SystemFS,
-			FrontMatter: mdv.CustomPageConfig.FrontMatter,
-			StartMdFile: htreq.FullDoc(),
-		})
-
-		if fm_param.MarkdownConfig != "" {
-			name := fm_param.MarkdownConfig
-			if name[0] != '/' {
-				name = rpath.Join(rpath.Dir(htreq.FullDoc()), name)
-			}
-			if strings.HasPrefix(name, mdv.DocumentRoot.String()) {
-				if md_cfg, err := md2html.NewMdConfig(mdv.SystemFS, name); err == nil {
-					m2h = m2h.NewLocalSpec(md_cfg)
-				}
-			}
-		}
-
-		doc_bin, toc_bin, md_title_bin, cerr = m2h.Convert(raw_bin)
+		m2h := mdv.newMd2Html(htreq.FullDoc(), fm_param)
+		res, cerr := m2h.ConvertWithDiagnostics(raw_bin)
 		if cerr != nil {
 			w.Error("500 conversion failed: "+cerr.Error(), http.StatusInternalServerError)
 			return
 		}
+		doc_bin, toc_bin, md_title_bin = res.Html, res.Toc, res.Title
+		table_list_bin = res.TableList
+		tasks = res.Tasks
+		inc_files = m2h.IncludedFiles()
 
 		if !with_title_param {
 			title_bin = md_title_bin
@@ -360,6 +439,7 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 	var f_list []*dirview.FileStamp = nil
 	if dir_view {
 		f_list = mdv.DirViewStamp.Get(htreq.Dir(), !is_dir)
+		inc_files = append(inc_files, dirview.TaskFiles(f_list)...)
 	}
 
 	link_menu := []md2html.Link{}
@@ -400,8 +480,11 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 		TextType:  text_type,
 		Title:     string(title_bin),
 		Toc:       string(toc_bin),
+		TableList: string(table_list_bin),
+		Tasks:     tasks,
 		Files:     f_list,
 		IsOpen:    is_open,
+		Search:    mdv.newTmplSearch(),
 
 		CustomParam: custom_param,
 	}
@@ -412,9 +495,7 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 		return
 	}
 
-	tmpl_funcs := template.FuncMap{}
-	tmplext.AddDefaultFunc(tmpl_funcs, mdv.SystemFS, mdv.SvgIconPath)
-	tmpl = tmpl.Funcs(tmpl_funcs)
+	tmpl = tmpl.Funcs(mdv.tmplFuncs())
 
 	var buf bytes.Buffer
 	if e := tmpl.Execute(&buf, tmpl_param); e != nil {
@@ -423,6 +504,14 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 		return
 	}
 
+	if mdv.RenderCache != nil {
+		tag = mdv.putRenderCache(cache_name, mod_time, &pcache.Entry{
+			ContentType: "text/html; charset=utf-8",
+			LastMod:     last_mod,
+			Body:        bytes.Clone(buf.Bytes()),
+		}, inc_files)
+	}
+
 	w_header.Set("Content-Type", "text/html; charset=utf-8")
 	w_header.Set("Last-Modified", last_mod)
 	w_header.Set("Etag", tag)
@@ -430,6 +519,19 @@ func (mdv *MdView) writeView(req_path string, r_header Getter, w HttpWriter) {
 	buf.WriteTo(w)
 }
 
+func (mdv *MdView) putRenderCache(name string, mod_time time.Time,
+	ent *pcache.Entry, inc_files []string) string {
+	ent.Name = name
+	ent.Deps = pcache.NewDeps(mdv.SystemFS, inc_files)
+	if dep_mod := pcache.LatestModTime(ent.Deps); dep_mod.After(mod_time) {
+		mod_time = dep_mod
+	}
+	ent.Tag = mdv.MakeEtag(mod_time)
+
+	mdv.RenderCache.Put(ent)
+	return ent.Tag
+}
+
 func tmplLookups(tmpl *template.Template, names ...string) *template.Template {
 	var tt *template.Template = nil
 	for _, n := range names {
@@ -481,6 +583,7 @@ func (mdv *MdView) SumTemplate() (*TmplSum, error) {
 		Toc:       "<ul></ul>",
 		Files:     nil,
 		IsOpen:    false,
+		Search:    mdv.newTmplSearch(),
 
 		CustomParam: md2html.CustomParam{},
 	}
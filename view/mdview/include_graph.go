@@ -0,0 +1,123 @@
+package mdview
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/l4go/rpath"
+)
+
+const includeGraphRefreshInterval = 5 * time.Second
+
+type includeGraphFile struct {
+	Path       string   `json:"path"`
+	Includes   []string `json:"includes"`
+	IncludedBy []string `json:"included_by"`
+	Dependents []string `json:"dependents"`
+}
+
+func (mdv *MdView) RefreshIncludeGraph() error {
+	mdv.graph_mtx.Lock()
+	defer mdv.graph_mtx.Unlock()
+
+	if mdv.graph_mods == nil {
+		mdv.graph_mods = map[string]time.Time{}
+	}
+
+	found := map[string]struct{}{}
+	err := mdv.walkMarkdown(func(rel_name string, mod_time time.Time) {
+		found[rel_name] = struct{}{}
+		if cur, ok := mdv.graph_mods[rel_name]; ok && cur.Equal(mod_time) {
+			return
+		}
+
+		full_doc := rpath.Join(mdv.DocumentRoot.String(), rel_name)
+		raw_bin, fm_param, err := mdv.readMarkdown(full_doc)
+		if err != nil {
+			mdv.IncludeGraph.Remove(full_doc)
+			return
+		}
+		mdv.newMd2Html(full_doc, fm_param).Convert(raw_bin)
+		mdv.graph_mods[rel_name] = mod_time
+	})
+	if err != nil {
+		return err
+	}
+
+	for rel_name := range mdv.graph_mods {
+		if _, ok := found[rel_name]; !ok {
+			delete(mdv.graph_mods, rel_name)
+			mdv.IncludeGraph.Remove(rpath.Join(mdv.DocumentRoot.String(), rel_name))
+		}
+	}
+	mdv.graph_time = time.Now()
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (mdv *MdView) graphRelPaths(files []string) []string {
+	root := rpath.SetDir(mdv.DocumentRoot.String())
+	lst := []string{}
+	for _, f := range files {
+		if !strings.HasPrefix(f, root) {
+			continue
+		}
+		lst = append(lst, rpath.Join("/", strings.TrimPrefix(f, root)))
+	}
+
+	return lst
+}
+
+func (mdv *MdView) includeGraphFile(rel_name string) *includeGraphFile {
+	full := rpath.Join(mdv.DocumentRoot.String(), rel_name)
+
+	return &includeGraphFile{
+		Path:       rel_name,
+		Includes:   mdv.graphRelPaths(mdv.IncludeGraph.Includes(full)),
+		IncludedBy: mdv.graphRelPaths(mdv.IncludeGraph.IncludedBy(full)),
+		Dependents: mdv.graphRelPaths(mdv.IncludeGraph.Dependents(full)),
+	}
+}
+
+func (mdv *MdView) writeIncludeGraph(rel_name string, w HttpWriter) {
+	mdv.graph_mtx.Lock()
+	stale := time.Since(mdv.graph_time) > includeGraphRefreshInterval
+	mdv.graph_mtx.Unlock()
+	if stale {
+		mdv.RefreshIncludeGraph()
+	}
+
+	var res any
+	if rel_name != "" {
+		res = mdv.includeGraphFile(rpath.Clean("/" + rel_name))
+	} else {
+		files := []*includeGraphFile{}
+		for _, f := range mdv.graphRelPaths(sortedKeys(mdv.IncludeGraph.Nodes())) {
+			files = append(files, mdv.includeGraphFile(f))
+		}
+		res = files
+	}
+
+	bin, err := json.Marshal(res)
+	if err != nil {
+		w.Error("500 include graph error", http.StatusInternalServerError)
+		return
+	}
+
+	w_header := w.Header()
+	w_header.Set("Content-Type", "application/json; charset=utf-8")
+	w_header.Set("Cache-Control", "no-store")
+	w.Write(bin)
+}
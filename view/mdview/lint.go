@@ -0,0 +1,87 @@
+package mdview
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/l4go/rpath"
+	"github.com/l4go/unifs"
+
+	"github.com/1f408/cats_eeds/md2html/diag"
+)
+
+func (mdv *MdView) lintDoc(rel_name string) []*diag.Diagnostic {
+	full_doc := rpath.Join(mdv.DocumentRoot.String(), rel_name)
+
+	raw_bin, err := unifs.ReadFile(mdv.SystemFS, full_doc)
+	if err != nil {
+		return []*diag.Diagnostic{{
+			Severity: diag.Error,
+			File:     full_doc,
+			Message:  "read error: " + err.Error(),
+		}}
+	}
+
+	fm_diags := []*diag.Diagnostic{}
+	md_bin, fm_param, fm_err := mdv.splitMarkdown(raw_bin)
+	if fm_err != nil {
+		fm_diags = append(fm_diags, &diag.Diagnostic{
+			Severity: diag.Error,
+			File:     full_doc,
+			Message:  "front matter error: " + fm_err.Error(),
+		})
+	}
+
+	res, err := mdv.newMd2Html(full_doc, fm_param).ConvertWithDiagnostics(md_bin)
+	if err != nil {
+		return append(fm_diags, &diag.Diagnostic{
+			Severity: diag.Error,
+			File:     full_doc,
+			Message:  "convert error: " + err.Error(),
+		})
+	}
+
+	return append(fm_diags, res.Diagnostics...)
+}
+
+func (mdv *MdView) lintRelPath(file string) string {
+	root := rpath.SetDir(mdv.DocumentRoot.String())
+	if !strings.HasPrefix(file, root) {
+		return file
+	}
+	return rpath.Join("/", strings.TrimPrefix(file, root))
+}
+
+// Lint converts every markdown document and writes its diagnostics to out.
+func (mdv *MdView) Lint(out io.Writer) ([]*diag.Diagnostic, error) {
+	diags := []*diag.Diagnostic{}
+	seen := map[string]struct{}{}
+	werr := mdv.walkMarkdown(func(rel_name string, _ time.Time) {
+		for _, d := range mdv.lintDoc(rel_name) {
+			d.File = mdv.lintRelPath(d.File)
+			if _, ok := seen[d.String()]; ok {
+				continue
+			}
+			seen[d.String()] = struct{}{}
+			diags = append(diags, d)
+		}
+	})
+	if werr != nil {
+		return nil, new_err("lint walk error: %v", werr)
+	}
+
+	errs := 0
+	for _, d := range diags {
+		fmt.Fprintln(out, d.String())
+		if d.Severity >= diag.Error {
+			errs++
+		}
+	}
+
+	if errs > 0 {
+		return diags, new_err("lint failed: %d errors", errs)
+	}
+	return diags, nil
+}
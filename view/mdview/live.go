@@ -0,0 +1,180 @@
+package mdview
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/l4go/rpath"
+	"github.com/l4go/unifs"
+
+	"github.com/1f408/cats_eeds/frontmatter"
+	"github.com/1f408/cats_eeds/md2html"
+	"github.com/1f408/cats_eeds/view/internal/htpath"
+	"github.com/1f408/cats_eeds/view/internal/tmplext"
+	"github.com/1f408/cats_eeds/view/internal/watch"
+)
+
+const liveReloadPing = 30 * time.Second
+
+func (mdv *MdView) tmplFuncs() template.FuncMap {
+	tmpl_funcs := template.FuncMap{
+		"live_reload": mdv.liveReloadScript,
+	}
+	tmplext.AddDefaultFunc(tmpl_funcs, mdv.SystemFS, mdv.SvgIconPath)
+
+	return tmpl_funcs
+}
+
+func (mdv *MdView) liveReloadScript(page_path string) string {
+	if mdv.LiveReloadPath == "" {
+		return ""
+	}
+
+	src := rpath.Join(mdv.UrlTopPath, mdv.LiveReloadPath) + "?path=" +
+		strings.ReplaceAll(template.URLQueryEscaper(page_path), "+", "%20")
+	src_js, _ := json.Marshal(src)
+
+	return `<script>(function(){var es=new EventSource(` + string(src_js) + `);` +
+		`es.addEventListener("changed",function(){es.close();location.reload();});})();</script>`
+}
+
+// tmplFiles returns the template files matched by the TmplPaths patterns.
+func (mdv *MdView) tmplFiles() []string {
+	files := []string{}
+	for _, tp := range mdv.TmplPaths {
+		names, err := fs.Glob(mdv.SystemFS, tp.FSPath())
+		if err != nil {
+			continue
+		}
+		for _, n := range names {
+			if f, err := unifs.FromFSPath(n); err == nil {
+				files = append(files, f)
+			}
+		}
+	}
+
+	return files
+}
+
+func (mdv *MdView) liveWatchFiles(req_path string) ([]string, error) {
+	htreq, err := htpath.New(mdv.SystemFS, mdv.DocumentRoot.String(), req_path, mdv.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{mdv.MarkdownConfigPath.String()}
+	files = append(files, mdv.tmplFiles()...)
+
+	if !htreq.HasDoc() {
+		return append(files, htreq.FullReq()), nil
+	}
+	files = append(files, htreq.FullDoc())
+	if htreq.IsDir() {
+		files = append(files, htreq.FullReq())
+	}
+
+	if kind := htreq.Kind(); kind != "text/markdown" {
+		return files, nil
+	}
+
+	raw_bin, err := unifs.ReadFile(mdv.SystemFS, htreq.FullDoc())
+	if err != nil {
+		return nil, err
+	}
+
+	fm_param := &md2html.FrontMatterParam{}
+	if mdv.CustomPageConfig.FrontMatter.IsEnabled() {
+		body, fmp, fm_err := mdv.CustomPageConfig.FrontMatter.TrimAndParse(raw_bin)
+		switch {
+		case fm_err == nil && fmp != nil:
+			raw_bin = body
+			fm_param = fmp
+		case fm_err == nil, fm_err == frontmatter.ErrNotFound:
+		default:
+			return files, nil
+		}
+	}
+	if fm_param.MarkdownConfig != "" {
+		name := fm_param.MarkdownConfig
+		if name[0] != '/' {
+			name = rpath.Join(rpath.Dir(htreq.FullDoc()), name)
+		}
+		files = append(files, name)
+	}
+
+	m2h := mdv.newMd2Html(htreq.FullDoc(), fm_param)
+	if _, _, _, err := m2h.Convert(raw_bin); err != nil {
+		return files, nil
+	}
+
+	return append(files, m2h.IncludedFiles()...), nil
+}
+
+func (mdv *MdView) liveReload(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "500 streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	page_path := r.URL.Query().Get("path")
+	if !strings.HasPrefix(page_path, mdv.UrlTopPath) {
+		http.Error(w, "400 bad page path", http.StatusBadRequest)
+		return
+	}
+	req_path := rpath.Clean("/" + strings.TrimPrefix(page_path, mdv.UrlTopPath))
+
+	uni_files, err := mdv.liveWatchFiles(req_path)
+	if err != nil {
+		http.Error(w, "404 not found", http.StatusNotFound)
+		return
+	}
+
+	os_files := make([]string, 0, len(uni_files))
+	for _, f := range uni_files {
+		if f == "" {
+			continue
+		}
+		if of, err := unifs.ToOSPath(f); err == nil {
+			os_files = append(os_files, of)
+		}
+	}
+
+	wt, err := watch.New(os_files)
+	if err != nil {
+		http.Error(w, "500 watch error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer wt.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": watching\n\n")
+	flusher.Flush()
+
+	ping := time.NewTicker(liveReloadPing)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case <-wt.Changed():
+			data, _ := json.Marshal(page_path)
+			fmt.Fprintf(w, "event: changed\ndata: %s\n\n", data)
+			flusher.Flush()
+			return
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package mdview
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/1f408/cats_eeds/upath"
+)
+
+func TestLiveWatchFilesTemplateGlob(t *testing.T) {
+	mdv, dst_dir := newExportTestView(t, map[string]string{
+		"doc/README.md":     "# Top\n",
+		"tmpl/mdview.tmpl":  "",
+		"tmpl/style_a.tmpl": "",
+		"tmpl/readme.txt":   "",
+	})
+	top := filepath.Dir(dst_dir)
+	mdv.MarkdownConfigPath = upath.MustNewByOS(filepath.Join(top, "md.conf"))
+	mdv.TmplPaths = []upath.UPath{
+		upath.MustNewByOS(filepath.Join(top, "tmpl", "*.tmpl")),
+		upath.MustNewByOS(filepath.Join(top, "none", "*.tmpl")),
+	}
+
+	files, err := mdv.liveWatchFiles("/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"mdview.tmpl", "style_a.tmpl"} {
+		f := upath.MustNewByOS(filepath.Join(top, "tmpl", name)).String()
+		if !slices.Contains(files, f) {
+			t.Errorf("%s is not watched: %v", f, files)
+		}
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f, ".txt") || strings.Contains(f, "*") {
+			t.Errorf("%s is watched", f)
+		}
+	}
+}
@@ -4,19 +4,23 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/l4go/rpath"
+	"github.com/l4go/unifs"
 
 	"github.com/1f408/cats_eeds/internal/ftype"
 	"github.com/1f408/cats_eeds/md2html"
 	"github.com/1f408/cats_eeds/upath"
 	"github.com/1f408/cats_eeds/view/internal/dirview"
 	"github.com/1f408/cats_eeds/view/internal/mtable"
-	"github.com/1f408/cats_eeds/view/internal/tmplext"
+	"github.com/1f408/cats_eeds/view/internal/pcache"
+	"github.com/1f408/cats_eeds/view/internal/search"
 )
 
 func is_abs_dir_path(p string) bool {
@@ -42,15 +46,17 @@ type MdView struct {
 
 	IndexName    string
 	OriginTmpl   *template.Template
+	TmplPaths    []upath.UPath
 	HtmlTmpl     *template.Template
 	MainTmplName string
 	SvgIconPath  upath.UPath
 
-	MimeExtTable      *mtable.MimeExtTable
-	MarkdownExt       []string
-	MarkdownConfig    *md2html.MdConfig
-	CustomPageConfig  *md2html.CustomPageConfig
-	PrintPaperMapping *md2html.PrintPaperMapping
+	MimeExtTable       *mtable.MimeExtTable
+	MarkdownExt        []string
+	MarkdownConfig     *md2html.MdConfig
+	MarkdownConfigPath upath.UPath
+	CustomPageConfig   *md2html.CustomPageConfig
+	PrintPaperMapping  *md2html.PrintPaperMapping
 
 	ThemeStyle   string
 	PageStyle    string
@@ -69,6 +75,21 @@ type MdView struct {
 
 	TextViewMode string
 
+	SearchPath  string
+	SearchIndex *search.Index
+	search_mtx  sync.Mutex
+	search_time time.Time
+
+	RenderCache *pcache.Cache
+
+	LiveReloadPath string
+
+	IncludeGraph     *md2html.IncludeGraph
+	IncludeGraphPath string
+	graph_mtx        sync.Mutex
+	graph_mods       map[string]time.Time
+	graph_time       time.Time
+
 	ConfigModTime time.Time
 	TemplateTag   []byte
 	SystemHtmlIds []string
@@ -96,6 +117,10 @@ func newMdViewDefault() *MdView {
 	return mdv
 }
 
+func (mdv *MdView) Warn(format string, v ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", v...)
+}
+
 func NewMdView(cfg *MdViewConfig) (*MdView, error) {
 	mdv := newMdViewDefault()
 
@@ -215,11 +240,53 @@ func NewMdView(cfg *MdViewConfig) (*MdView, error) {
 		return nil, new_err("Bad text view mode: %s", mdv.TextViewMode)
 	}
 
-	mdv.OriginTmpl = template.New("")
-	tmpl_funcs := template.FuncMap{}
-	tmplext.AddDefaultFunc(tmpl_funcs, mdv.SystemFS, mdv.SvgIconPath)
+	if cfg.RenderCacheSize < 0 {
+		return nil, new_err("Bad render cache size: %d", cfg.RenderCacheSize)
+	}
+	if cfg.RenderCacheSize > 0 {
+		disk_dir := ""
+		if !cfg.RenderCacheDir.IsZero() {
+			os_dir, err := unifs.ToOSPath(cfg.RenderCacheDir.String())
+			if err != nil {
+				return nil, new_err("Bad render cache directory: %s", cfg.RenderCacheDir.String())
+			}
+			if fi, err := os.Stat(os_dir); err != nil || !fi.IsDir() {
+				return nil, new_err("Not found render cache directory: %s", cfg.RenderCacheDir.String())
+			}
+			disk_dir = os_dir
+		}
+		mdv.RenderCache = pcache.New(mdv.SystemFS, cfg.RenderCacheSize, disk_dir)
+	}
+
+	if cfg.SearchPath != "" {
+		mdv.SearchPath = cfg.SearchPath
+		if rpath.Clean(mdv.SearchPath) != mdv.SearchPath || !rpath.IsAbs(mdv.SearchPath) ||
+			rpath.IsDir(mdv.SearchPath) {
+			return nil, new_err("Bad search path: %s", mdv.SearchPath)
+		}
+		mdv.SearchIndex = search.NewIndex()
+	}
+
+	if cfg.LiveReloadPath != "" {
+		mdv.LiveReloadPath = cfg.LiveReloadPath
+		if rpath.Clean(mdv.LiveReloadPath) != mdv.LiveReloadPath || !rpath.IsAbs(mdv.LiveReloadPath) ||
+			rpath.IsDir(mdv.LiveReloadPath) {
+			return nil, new_err("Bad live reload path: %s", mdv.LiveReloadPath)
+		}
+	}
+
+	mdv.IncludeGraph = md2html.NewIncludeGraph()
+	if cfg.IncludeGraphPath != "" {
+		mdv.IncludeGraphPath = cfg.IncludeGraphPath
+		if rpath.Clean(mdv.IncludeGraphPath) != mdv.IncludeGraphPath || !rpath.IsAbs(mdv.IncludeGraphPath) ||
+			rpath.IsDir(mdv.IncludeGraphPath) {
+			return nil, new_err("Bad include graph path: %s", mdv.IncludeGraphPath)
+		}
+	}
 
-	mdv.OriginTmpl = mdv.OriginTmpl.Funcs(tmpl_funcs)
+	mdv.TmplPaths = cfg.TmplPaths
+	mdv.OriginTmpl = template.New("")
+	mdv.OriginTmpl = mdv.OriginTmpl.Funcs(mdv.tmplFuncs())
 	mdv.OriginTmpl, err = mdv.OriginTmpl.ParseFS(mdv.SystemFS, upath.FSPaths(cfg.TmplPaths)...)
 
 	if err != nil {
@@ -249,9 +316,13 @@ func NewMdView(cfg *MdViewConfig) (*MdView, error) {
 	}
 
 	mdv.MarkdownConfig = cfg.MarkdownConfig.Value
+	mdv.MarkdownConfigPath = cfg.MarkdownConfig.UPath
 
 	mdv.CustomPageConfig = cfg.CustomPageConfig.Value
 	mdv.PrintPaperMapping = mdv.CustomPageConfig.PrintPaper.Mapping.Value
+	if mdv.MarkdownConfig.Extension.TaskList {
+		mdv.DirViewStamp.SetTaskCounter(mdv.countTasks)
+	}
 
 	if cfg.ThemeStyle != "" {
 		mdv.ThemeStyle = cfg.ThemeStyle
@@ -0,0 +1,266 @@
+package mdview
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/l4go/rpath"
+	"github.com/l4go/unifs"
+
+	"github.com/1f408/cats_eeds/frontmatter"
+	"github.com/1f408/cats_eeds/internal/ftype"
+	"github.com/1f408/cats_eeds/internal/perenc"
+	"github.com/1f408/cats_eeds/md2html"
+	"github.com/1f408/cats_eeds/upath"
+	"github.com/1f408/cats_eeds/view/internal/links"
+	"github.com/1f408/cats_eeds/view/internal/search"
+)
+
+const searchRefreshInterval = 5 * time.Second
+const searchHitLimit = 50
+
+type tmplSearch struct {
+	Path  string
+	Query string
+	Hits  []*search.Hit
+}
+
+func (mdv *MdView) newTmplSearch() *tmplSearch {
+	if mdv.SearchIndex == nil {
+		return nil
+	}
+
+	return &tmplSearch{Path: rpath.Join(mdv.UrlTopPath, mdv.SearchPath)}
+}
+
+func (mdv *MdView) walkMarkdown(fn func(rel_name string, mod_time time.Time)) error {
+	return mdv.DirViewStamp.Walk(func(rel_name string, root upath.UPath, fi fs.FileInfo) error {
+		if fi.IsDir() || root != mdv.DocumentRoot {
+			return nil
+		}
+		if kind, _ := ftype.GetFileKindByExt(rpath.Ext(rel_name)); kind != "text/markdown" {
+			return nil
+		}
+
+		mod_time := fi.ModTime()
+		if mdv.ConfigModTime.After(mod_time) {
+			mod_time = mdv.ConfigModTime
+		}
+		fn(rel_name, mod_time)
+		return nil
+	})
+}
+
+func (mdv *MdView) readMarkdown(full_doc string) ([]byte, *md2html.FrontMatterParam, error) {
+	raw_bin, err := unifs.ReadFile(mdv.SystemFS, full_doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	md_bin, fm_param, _ := mdv.splitMarkdown(raw_bin)
+	return md_bin, fm_param, nil
+}
+
+// splitMarkdown trims the front matter from raw_bin.
+// On a front matter error, it returns raw_bin with the empty parameter.
+func (mdv *MdView) splitMarkdown(raw_bin []byte) ([]byte, *md2html.FrontMatterParam, error) {
+	fm_param := &md2html.FrontMatterParam{}
+	if !mdv.CustomPageConfig.FrontMatter.IsEnabled() {
+		return raw_bin, fm_param, nil
+	}
+
+	body, fmp, fm_err := mdv.CustomPageConfig.FrontMatter.TrimAndParse(raw_bin)
+	switch {
+	case fm_err == nil && fmp != nil:
+		return body, fmp, nil
+	case fm_err == nil, fm_err == frontmatter.ErrNotFound:
+		return raw_bin, fm_param, nil
+	}
+
+	return raw_bin, fm_param, fm_err
+}
+
+func (mdv *MdView) indexDoc(rel_name string, mod_time time.Time) error {
+	full_doc := rpath.Join(mdv.DocumentRoot.String(), rel_name)
+	raw_bin, fm_param, err := mdv.readMarkdown(full_doc)
+	if err != nil {
+		return err
+	}
+
+	doc_bin, _, title_bin, err := mdv.newMd2Html(full_doc, fm_param).Convert(raw_bin)
+	if err != nil {
+		return err
+	}
+	text, err := md2html.ConvertHtmlText(doc_bin)
+	if err != nil {
+		return err
+	}
+
+	title := string(title_bin)
+	if fm_param.Title != "" {
+		title = fm_param.Title
+	}
+
+	mdv.SearchIndex.Put(&search.Document{
+		Path:    rel_name,
+		Title:   title,
+		Text:    text,
+		ModTime: mod_time,
+	})
+	return nil
+}
+
+func (mdv *MdView) RefreshSearchIndex() error {
+	if mdv.SearchIndex == nil {
+		return nil
+	}
+
+	mdv.search_mtx.Lock()
+	defer mdv.search_mtx.Unlock()
+
+	return mdv.refreshSearchIndex()
+}
+
+// refreshStaleSearchIndex refreshes the index only when it is older than
+// searchRefreshInterval. The concurrent callers wait for one refresh.
+func (mdv *MdView) refreshStaleSearchIndex() error {
+	mdv.search_mtx.Lock()
+	defer mdv.search_mtx.Unlock()
+
+	if time.Since(mdv.search_time) <= searchRefreshInterval {
+		return nil
+	}
+	return mdv.refreshSearchIndex()
+}
+
+func (mdv *MdView) refreshSearchIndex() error {
+	// a failed refresh is not retried until the next interval.
+	mdv.search_time = time.Now()
+
+	found := map[string]struct{}{}
+	err := mdv.walkMarkdown(func(rel_name string, mod_time time.Time) {
+		found[rel_name] = struct{}{}
+		if cur, ok := mdv.SearchIndex.ModTime(rel_name); ok && cur.Equal(mod_time) {
+			return
+		}
+
+		if err := mdv.indexDoc(rel_name, mod_time); err != nil {
+			mdv.SearchIndex.Remove(rel_name)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range mdv.SearchIndex.Paths() {
+		if _, ok := found[p]; !ok {
+			mdv.SearchIndex.Remove(p)
+		}
+	}
+
+	return nil
+}
+
+func (mdv *MdView) searchHits(query string) []*search.Hit {
+	if err := mdv.refreshStaleSearchIndex(); err != nil {
+		mdv.Warn("search index refresh error: %v", err)
+	}
+
+	hits := mdv.SearchIndex.Search(query, searchHitLimit)
+	for _, h := range hits {
+		h.Path = perenc.EncodeUrlPath(rpath.Join(mdv.UrlTopPath, h.Path))
+	}
+	return hits
+}
+
+func (mdv *MdView) writeSearch(query string, r_header Getter, w HttpWriter) {
+	w_header := w.Header()
+	hits := mdv.searchHits(query)
+
+	if !strings.Contains(r_header.Get("Accept"), "text/html") {
+		bin, err := json.Marshal(hits)
+		if err != nil {
+			w.Error("500 search result error", http.StatusInternalServerError)
+			return
+		}
+
+		w_header.Set("Content-Type", "application/json; charset=utf-8")
+		w_header.Set("Cache-Control", "no-store")
+		w.Write(bin)
+		return
+	}
+
+	tmpl, err := mdv.OriginTmpl.Clone()
+	if err != nil {
+		w.Error("503 service unavailable: "+err.Error(),
+			http.StatusServiceUnavailable)
+		return
+	}
+	tmpl = tmplLookups(tmpl, mdv.MainTmplName)
+	if tmpl == nil {
+		w.Error("503 not found template", http.StatusServiceUnavailable)
+		return
+	}
+
+	tmpl = tmpl.Funcs(mdv.tmplFuncs())
+
+	// The templates are text/template, so every value taken from the query
+	// or the documents is escaped here.
+	search_param := mdv.newTmplSearch()
+	search_param.Query = html.EscapeString(query)
+	search_param.Hits = make([]*search.Hit, len(hits))
+	for i, h := range hits {
+		eh := *h
+		eh.Title = html.EscapeString(h.Title)
+		search_param.Hits[i] = &eh
+	}
+
+	tmpl_param := tmplParam{
+		Options: &tmplOptions{
+			ThemeStyle:    mdv.ThemeStyle,
+			PageStyle:     mdv.PageStyle,
+			PrintSizeCss:  mdv.PrintSizeCss,
+			PrintZoom:     mdv.PrintZoom,
+			LocationNavi:  mdv.LocationNavi,
+			TocNavi:       mdv.TocNavi,
+			DirectoryView: false,
+		},
+		Markdown:  mdv.MarkdownConfig,
+		Top:       mdv.UrlTopPath,
+		Lib:       mdv.UrlLibPath,
+		Path:      search_param.Path,
+		PathLinks: links.NewLinks(rpath.Join("/", mdv.SearchPath)),
+		LinkMenu:  []md2html.Link{},
+		Text:      "",
+		TextType:  "",
+		Title:     "Search: " + search_param.Query,
+		Toc:       "",
+		Files:     nil,
+		IsOpen:    false,
+		Search:    search_param,
+
+		CustomParam: md2html.CustomParam{},
+	}
+	if mdv.CustomPageConfig.LinkMenu.Default != nil {
+		tmpl_param.LinkMenu = mdv.CustomPageConfig.LinkMenu.Default
+	}
+	if mdv.CustomPageConfig.CustomParam.Default != nil {
+		tmpl_param.CustomParam = mdv.CustomPageConfig.CustomParam.Default
+	}
+
+	var buf bytes.Buffer
+	if e := tmpl.Execute(&buf, tmpl_param); e != nil {
+		w.Error("503 template execute error:"+e.Error(),
+			http.StatusServiceUnavailable)
+		return
+	}
+
+	w_header.Set("Content-Type", "text/html; charset=utf-8")
+	w_header.Set("Cache-Control", "no-store")
+	buf.WriteTo(w)
+}
@@ -0,0 +1,52 @@
+package mdview
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1f408/cats_eeds/view/internal/search"
+)
+
+func TestRefreshStaleSearchIndex(t *testing.T) {
+	mdv, dst_dir := newExportTestView(t, map[string]string{
+		"doc/README.md": "# Top\n\nalpha\n",
+	})
+	mdv.SearchIndex = search.NewIndex()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := mdv.refreshStaleSearchIndex(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if hits := mdv.SearchIndex.Search("alpha", 0); len(hits) != 1 {
+		t.Fatalf("got %+v", hits)
+	}
+
+	doc := filepath.Join(filepath.Dir(dst_dir), "doc", "new.md")
+	if err := os.WriteFile(doc, []byte("# New\n\nbeta\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mdv.refreshStaleSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if hits := mdv.SearchIndex.Search("beta", 0); len(hits) != 0 {
+		t.Errorf("fresh index is refreshed: %+v", hits)
+	}
+
+	mdv.search_time = time.Now().Add(-2 * searchRefreshInterval)
+	if err := mdv.refreshStaleSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if hits := mdv.SearchIndex.Search("beta", 0); len(hits) != 1 {
+		t.Errorf("stale index is not refreshed: %+v", hits)
+	}
+}
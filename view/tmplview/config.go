@@ -62,6 +62,12 @@ type tmplConfig struct {
 
 	TextViewMode string `toml:",omitempty"`
 
+	TaskTogglePath  string `toml:",omitempty"`
+	TaskToggleAuthz string `toml:",omitempty"`
+
+	RenderCacheSize int         `toml:",omitempty"`
+	RenderCacheDir  upath.UPath `toml:",omitempty"`
+
 	CatUiConfigPath upath.UPath `toml:",omitempty"`
 	CatUiConfigExt  string      `toml:",omitempty"`
 	CatUiTmplName   string      `toml:",omitempty"`
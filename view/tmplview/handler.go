@@ -26,6 +26,7 @@ import (
 	"github.com/1f408/cats_eeds/view/internal/etag"
 	"github.com/1f408/cats_eeds/view/internal/htpath"
 	"github.com/1f408/cats_eeds/view/internal/links"
+	"github.com/1f408/cats_eeds/view/internal/pcache"
 	"github.com/1f408/cats_eeds/view/internal/tmplext"
 )
 
@@ -75,6 +76,7 @@ type tmplMdParam struct {
 	Text     string
 	TextType string
 	Toc      string
+	Tasks    md2html.TaskCount
 
 	CustomParam md2html.CustomParam
 }
@@ -96,6 +98,22 @@ func (tmpv *TmplView) MakeEtag(t time.Time, user string) string {
 	return etag.Make(tmpv.TemplateTag, tm, etag.Crypt(tm, []byte(user)))
 }
 
+func (tmpv *TmplView) viewModTime(htreq *htpath.HttpPath, user string) time.Time {
+	mod_time := htreq.ModTime()
+	if mod_time.Before(tmpv.ConfigModTime) {
+		mod_time = tmpv.ConfigModTime
+	}
+
+	cache_name := htreq.FullReq() + "\x00" + user
+	if tmpv.RenderCache != nil {
+		if dep_mod := tmpv.RenderCache.DepsModTime(cache_name); dep_mod.After(mod_time) {
+			mod_time = dep_mod
+		}
+	}
+
+	return mod_time
+}
+
 func isModified(hd Getter, org_tag string, mod_time time.Time) bool {
 	if_nmatch := hd.Get("If-None-Match")
 
@@ -117,13 +135,18 @@ func isEtagMatch(tag_str string, org_tag string) bool {
 }
 
 func (tmpv *TmplView) Handler(w http.ResponseWriter, r *http.Request) {
+	req_path := rpath.Clean("/" + r.URL.Path)
+	if r.Method == "POST" && tmpv.TaskTogglePath != "" && req_path == tmpv.TaskTogglePath {
+		tmpv.taskToggle(w, r)
+		return
+	}
+
 	if r.Method != "GET" {
 		http.Error(w, "405 not supported "+r.Method+" method",
 			http.StatusMethodNotAllowed)
 		return
 	}
 
-	req_path := rpath.Clean("/" + r.URL.Path)
 	tmpv.writeView(req_path, r.Header, NewHttpWriter(w, r))
 }
 
@@ -135,6 +158,35 @@ func (tmpv *TmplView) Dump(out, eout io.Writer, req_path string) {
 	tmpv.writeView(req_path, h, w)
 }
 
+func (tmpv *TmplView) newMd2Html(full_doc string, fm_param *md2html.FrontMatterParam) *md2html.Md2Html {
+	m2h := md2html.NewMd2Html(&md2html.Md2HtmlConfig{
+		MdConfig:    tmpv.MarkdownConfig,
+		SystemIds:   tmpv.SystemHtmlIds,
+		SystemFS:    tmpv.SystemFS,
+		FrontMatter: tmpv.CustomPageConfig.FrontMatter,
+		StartMdFile: full_doc,
+
+		DocumentRoot: tmpv.DocumentRoot.String(),
+		TaskIndex:    tmpv.TaskTogglePath != "",
+		TocParam:     fm_param.Toc,
+		LineOffset:   fm_param.LineOffset,
+	})
+
+	if fm_param.MarkdownConfig != "" {
+		name := fm_param.MarkdownConfig
+		if name[0] != '/' {
+			name = rpath.Join(rpath.Dir(full_doc), name)
+		}
+		if strings.HasPrefix(name, tmpv.DocumentRoot.String()) {
+			if md_cfg, err := md2html.NewMdConfig(tmpv.SystemFS, name); err == nil {
+				m2h = m2h.NewLocalSpec(md_cfg)
+			}
+		}
+	}
+
+	return m2h
+}
+
 func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter) {
 	w_header := w.Header()
 	htreq, ht_err := htpath.New(tmpv.SystemFS, tmpv.DocumentRoot.String(),
@@ -203,12 +255,11 @@ func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter)
 	case "dir":
 	}
 
-	mod_time := htreq.ModTime()
-	if mod_time.Before(tmpv.ConfigModTime) {
-		mod_time = tmpv.ConfigModTime
-	}
+	mod_time := tmpv.viewModTime(htreq, user)
 	last_mod := htreq.LastMod()
 
+	cache_name := htreq.FullReq() + "\x00" + user
+
 	tag := tmpv.MakeEtag(mod_time, user)
 	if !isModified(r_header, tag, mod_time) {
 		w_header.Set("Last-Modified", last_mod)
@@ -217,6 +268,17 @@ func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter)
 		return
 	}
 
+	if tmpv.RenderCache != nil {
+		if ent, ok := tmpv.RenderCache.Get(cache_name, tag); ok {
+			w_header.Set("Content-Type", ent.ContentType)
+			w_header.Set("Last-Modified", ent.LastMod)
+			w_header.Set("Etag", ent.Tag)
+			tmpv.setCacheHeader(w_header)
+			w.Write(ent.Body)
+			return
+		}
+	}
+
 	var raw_bin []byte
 	var fm_param *md2html.FrontMatterParam = &md2html.FrontMatterParam{}
 	if has_doc {
@@ -418,12 +480,21 @@ func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter)
 
 	var doc_bin []byte
 	var toc_bin []byte
+	var inc_files []string
+	var tasks md2html.TaskCount
 
 	switch proc_type {
 	default:
 		w.Error("500 media handling error", http.StatusInternalServerError)
 		return
 	case "html":
+		if tmpv.RenderCache != nil {
+			tag = tmpv.putRenderCache(cache_name, user, mod_time, &pcache.Entry{
+				ContentType: mime,
+				LastMod:     last_mod,
+				Body:        bytes.Clone(buf.Bytes()),
+			}, dirview.TaskFiles(f_list))
+		}
 		w_header.Set("Content-Type", mime)
 		w_header.Set("Last-Modified", last_mod)
 		w_header.Set("Etag", tag)
@@ -438,25 +509,7 @@ func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter)
 		doc_bin = buf.Bytes()
 		toc_bin = []byte{}
 	case "md":
-		m2h := md2html.NewMd2Html(&md2html.Md2HtmlConfig{
-			MdConfig:    tmpv.MarkdownConfig,
-			SystemIds:   tmpv.SystemHtmlIds,
-			SystemFS:    tmpv.SystemFS,
-			FrontMatter: tmpv.CustomPageConfig.FrontMatter,
-			StartMdFile: htreq.FullDoc(),
-		})
-
-		if fm_param.MarkdownConfig != "" {
-			name := fm_param.MarkdownConfig
-			if name[0] != '/' {
-				name = rpath.Join(rpath.Dir(htreq.FullDoc()), name)
-			}
-			if strings.HasPrefix(name, tmpv.DocumentRoot.String()) {
-				if md_cfg, err := md2html.NewMdConfig(tmpv.SystemFS, name); err == nil {
-					m2h = m2h.NewLocalSpec(md_cfg)
-				}
-			}
-		}
+		m2h := tmpv.newMd2Html(htreq.FullDoc(), fm_param)
 
 		var cerr error
 		var md_title_bin []byte
@@ -465,6 +518,8 @@ func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter)
 			w.Error("500 conversion failed: "+cerr.Error(), http.StatusInternalServerError)
 			return
 		}
+		inc_files = m2h.IncludedFiles()
+		tasks = m2h.TaskCount()
 
 		if !with_title_param {
 			title_bin = md_title_bin
@@ -499,6 +554,7 @@ func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter)
 		Text:     string(doc_bin),
 		TextType: text_type,
 		Toc:      string(toc_bin),
+		Tasks:    tasks,
 
 		CustomParam: custom_param,
 	}
@@ -516,6 +572,14 @@ func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter)
 		return
 	}
 
+	if tmpv.RenderCache != nil {
+		tag = tmpv.putRenderCache(cache_name, user, mod_time, &pcache.Entry{
+			ContentType: "text/html; charset=UTF-8",
+			LastMod:     last_mod,
+			Body:        bytes.Clone(mdbuf.Bytes()),
+		}, append(inc_files, dirview.TaskFiles(f_list)...))
+	}
+
 	w_header.Set("Content-Type", "text/html; charset=UTF-8")
 	w_header.Set("Last-Modified", last_mod)
 	w_header.Set("Etag", tag)
@@ -523,6 +587,19 @@ func (tmpv *TmplView) writeView(req_path string, r_header Getter, w HttpWriter)
 	mdbuf.WriteTo(w)
 }
 
+func (tmpv *TmplView) putRenderCache(name string, user string, mod_time time.Time,
+	ent *pcache.Entry, inc_files []string) string {
+	ent.Name = name
+	ent.Deps = pcache.NewDeps(tmpv.SystemFS, inc_files)
+	if dep_mod := pcache.LatestModTime(ent.Deps); dep_mod.After(mod_time) {
+		mod_time = dep_mod
+	}
+	ent.Tag = tmpv.MakeEtag(mod_time, user)
+
+	tmpv.RenderCache.Put(ent)
+	return ent.Tag
+}
+
 func tmplLookups(tmpl *template.Template, names ...string) *template.Template {
 	var tt *template.Template = nil
 	for _, n := range names {
@@ -0,0 +1,232 @@
+package tmplview
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/l4go/rpath"
+	"github.com/l4go/unifs"
+
+	"github.com/1f408/cats_eeds/frontmatter"
+	"github.com/1f408/cats_eeds/md2html"
+	"github.com/1f408/cats_eeds/view/internal/dirview"
+	"github.com/1f408/cats_eeds/view/internal/htpath"
+)
+
+var ErrBadTaskIndex = errors.New("bad task index")
+
+func (tmpv *TmplView) taskToggleScript(page_path string) string {
+	if tmpv.TaskTogglePath == "" {
+		return ""
+	}
+
+	src := rpath.Join(tmpv.UrlTopPath, tmpv.TaskTogglePath) + "?path=" +
+		strings.ReplaceAll(template.URLQueryEscaper(page_path), "+", "%20")
+	src_js, _ := json.Marshal(src)
+
+	return `<script>(function(){var src=` + string(src_js) + `,tag=null;` +
+		`function etag(){return tag?Promise.resolve(tag):fetch(location.href,{cache:"no-cache"}).then(function(r){return tag=r.headers.get("Etag");});}` +
+		`document.querySelectorAll("input[data-task-index]").forEach(function(cb){cb.disabled=false;` +
+		`cb.addEventListener("change",function(){cb.disabled=true;` +
+		`etag().then(function(t){return fetch(src,{method:"POST",headers:{"If-Match":t},body:new URLSearchParams({index:cb.dataset.taskIndex})});})` +
+		`.then(function(r){if(!r.ok){location.reload();return;}tag=r.headers.get("Etag");cb.disabled=false;})` +
+		`.catch(function(){location.reload();});});});})();</script>`
+}
+
+// toggleTaskMark flips the Nth task mark of the markdown document.
+// The marks are counted in the same way as the data-task-index attributes
+// of the rendered page, but tasks made by the template are not counted.
+func (tmpv *TmplView) toggleTaskMark(full_doc string, raw_bin []byte, index int) ([]byte, error) {
+	md_bin := raw_bin
+	fm_param := &md2html.FrontMatterParam{}
+	if tmpv.CustomPageConfig.FrontMatter.IsEnabled() {
+		body, fmp, fm_err := tmpv.CustomPageConfig.FrontMatter.TrimAndParse(raw_bin)
+		switch {
+		case fm_err == nil && fmp != nil:
+			md_bin = body
+			fm_param = fmp
+		case fm_err == nil, fm_err == frontmatter.ErrNotFound:
+		default:
+			return nil, fm_err
+		}
+	}
+	if !bytes.HasSuffix(raw_bin, md_bin) {
+		return nil, ErrBadTaskIndex
+	}
+	head := len(raw_bin) - len(md_bin)
+
+	marks := tmpv.newMd2Html(full_doc, fm_param).TaskMarks(md_bin)
+	if index < 0 || index >= len(marks) {
+		return nil, ErrBadTaskIndex
+	}
+
+	new_bin := bytes.Clone(raw_bin)
+	pos := head + marks[index]
+	switch new_bin[pos] {
+	case 'x', 'X':
+		new_bin[pos] = ' '
+	default:
+		new_bin[pos] = 'x'
+	}
+
+	return new_bin, nil
+}
+
+func (tmpv *TmplView) countTasks(full_doc string) (dirview.TaskCount, error) {
+	raw_bin, err := unifs.ReadFile(tmpv.SystemFS, full_doc)
+	if err != nil {
+		return dirview.TaskCount{}, err
+	}
+
+	if tmpv.CustomPageConfig.FrontMatter.IsEnabled() {
+		body, fmp, fm_err := tmpv.CustomPageConfig.FrontMatter.TrimAndParse(raw_bin)
+		if fm_err == nil && fmp != nil {
+			raw_bin = body
+		}
+	}
+
+	tc := md2html.CountTasks(raw_bin)
+	return dirview.TaskCount{Total: tc.Total, Done: tc.Done}, nil
+}
+
+// writeFileAtomic replaces the file with data through a temporary file.
+// A symbolic link is resolved first, so the link itself is kept.
+// When the owner of the file can not be kept, the file is left untouched.
+func writeFileAtomic(name string, data []byte) error {
+	name, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := chownAs(tmp, fi); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(fi.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (tmpv *TmplView) taskToggle(w http.ResponseWriter, r *http.Request) {
+	user := r.Header.Get(tmpv.AuthnUserHeader)
+	if !tmpv.UserMap.Authz(tmpv.TaskToggleAuthz, user) {
+		http.Error(w, "403 forbidden", http.StatusForbidden)
+		return
+	}
+
+	page_path := r.URL.Query().Get("path")
+	if !strings.HasPrefix(page_path, tmpv.UrlTopPath) {
+		http.Error(w, "400 bad page path", http.StatusBadRequest)
+		return
+	}
+	req_path := rpath.Clean("/" + strings.TrimPrefix(page_path, tmpv.UrlTopPath))
+
+	index, err := strconv.Atoi(r.PostFormValue("index"))
+	if err != nil {
+		http.Error(w, "400 bad task index", http.StatusBadRequest)
+		return
+	}
+
+	if_match := r.Header.Get("If-Match")
+	if if_match == "" {
+		http.Error(w, "428 If-Match required", http.StatusPreconditionRequired)
+		return
+	}
+
+	tmpv.task_mu.Lock()
+	defer tmpv.task_mu.Unlock()
+
+	htreq, ht_err := tmpv.taskDoc(req_path)
+	switch {
+	case ht_err == nil:
+	case os.IsNotExist(ht_err):
+		http.Error(w, "404 not found", http.StatusNotFound)
+		return
+	default:
+		http.Error(w, "400 bad request path", http.StatusBadRequest)
+		return
+	}
+
+	if !isEtagMatch(if_match, tmpv.MakeEtag(tmpv.viewModTime(htreq, user), user)) {
+		http.Error(w, "409 document changed", http.StatusConflict)
+		return
+	}
+
+	raw_bin, err := unifs.ReadFile(tmpv.SystemFS, htreq.FullDoc())
+	if err != nil {
+		http.Error(w, "500 document file read error", http.StatusInternalServerError)
+		return
+	}
+	new_bin, err := tmpv.toggleTaskMark(htreq.FullDoc(), raw_bin, index)
+	switch {
+	case err == nil:
+	case err == ErrBadTaskIndex:
+		http.Error(w, "400 bad task index", http.StatusBadRequest)
+		return
+	default:
+		http.Error(w, "500 Frontmatter error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	os_file, err := unifs.ToOSPath(htreq.FullDoc())
+	if err != nil {
+		http.Error(w, "500 document file write error", http.StatusInternalServerError)
+		return
+	}
+	if err := writeFileAtomic(os_file, new_bin); err != nil {
+		http.Error(w, "500 document file write error", http.StatusInternalServerError)
+		return
+	}
+
+	if htreq, err := tmpv.taskDoc(req_path); err == nil {
+		w.Header().Set("Etag", tmpv.MakeEtag(tmpv.viewModTime(htreq, user), user))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (tmpv *TmplView) taskDoc(req_path string) (*htpath.HttpPath, error) {
+	htreq, err := htpath.New(tmpv.SystemFS, tmpv.DocumentRoot.String(),
+		req_path, tmpv.IndexName)
+	if err != nil {
+		return nil, err
+	}
+	if !htreq.HasDoc() || htreq.Kind() != "text/markdown" {
+		return nil, htpath.ErrBadRequestType
+	}
+	if dir_mod, ok := tmpv.DirViewStamp.DirModTime(htreq.Dir()); ok {
+		htreq.UpdateModTime(dir_mod)
+	}
+
+	return htreq, nil
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package tmplview
+
+import (
+	"os"
+)
+
+func chownAs(f *os.File, fi os.FileInfo) error {
+	return nil
+}
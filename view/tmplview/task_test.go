@@ -0,0 +1,238 @@
+package tmplview
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/l4go/osfs"
+
+	"github.com/1f408/cats_eeds/authz"
+	"github.com/1f408/cats_eeds/internal/ftype"
+	"github.com/1f408/cats_eeds/md2html"
+	"github.com/1f408/cats_eeds/upath"
+	"github.com/1f408/cats_eeds/view/internal/dirview"
+)
+
+func newTaskTestView(t *testing.T) (*TmplView, string) {
+	t.Helper()
+	if err := ftype.SetMarkdownExt("md"); err != nil {
+		t.Fatal(err)
+	}
+
+	top := t.TempDir()
+	doc_dir := filepath.Join(top, "doc")
+	if err := os.Mkdir(doc_dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, text string) {
+		if err := os.WriteFile(filepath.Join(top, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("md.conf", "")
+	write("users", "alice\n")
+
+	cfg, err := authz.NewUserMapConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	umap, err := authz.NewUserMap(filepath.Join(top, "users"), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md_cfg, err := md2html.NewMdConfig(osfs.OsRootFS, filepath.Join(top, "md.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpv := newTmplViewDefault()
+	tmpv.SystemFS = osfs.OsRootFS
+	tmpv.UserMap = umap
+	tmpv.DocumentRoot = upath.MustNewByOS(doc_dir)
+	tmpv.MarkdownConfig = md_cfg
+	tmpv.CustomPageConfig = &md2html.CustomPageConfig{
+		FrontMatter: md2html.FrontMatterConfig{Yaml: true, UsedForHtml: true},
+	}
+	tmpv.TaskTogglePath = "/.task"
+	tmpv.DirViewStamp, err = dirview.NewDirViewStamp(tmpv.SystemFS,
+		[]upath.UPath{tmpv.DocumentRoot}, "%F %T", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tmpv, doc_dir
+}
+
+func taskEtag(t *testing.T, tmpv *TmplView, req_path string, user string) string {
+	t.Helper()
+	htreq, err := tmpv.taskDoc(req_path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpv.MakeEtag(tmpv.viewModTime(htreq, user), user)
+}
+
+func postToggle(tmpv *TmplView, page string, index string, user string, if_match string) *httptest.ResponseRecorder {
+	body := url.Values{"index": {index}}.Encode()
+	r := httptest.NewRequest("POST", "/.task?path="+url.QueryEscape(page), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != "" {
+		r.Header.Set(tmpv.AuthnUserHeader, user)
+	}
+	if if_match != "" {
+		r.Header.Set("If-Match", if_match)
+	}
+
+	w := httptest.NewRecorder()
+	tmpv.taskToggle(w, r)
+	return w
+}
+
+func TestTaskToggle(t *testing.T) {
+	tmpv, doc_dir := newTaskTestView(t)
+
+	src := "---\ntitle: tasks\n---\n# Tasks\n\n- [ ] one\n- [x] two\n  - [ ] three\n\n```\n- [ ] code\n```\n"
+	file := filepath.Join(doc_dir, "tasks.md")
+	if err := os.WriteFile(file, []byte(src), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(file, old, old)
+
+	tag := taskEtag(t, tmpv, "/tasks.md", "alice")
+	stale := tmpv.MakeEtag(time.Unix(0, 0), "alice")
+
+	cases := []struct {
+		name  string
+		user  string
+		page  string
+		index string
+		tag   string
+		code  int
+	}{
+		{"unknown user", "mallory", "/tasks.md", "0", tag, http.StatusForbidden},
+		{"no user", "", "/tasks.md", "0", tag, http.StatusForbidden},
+		{"no If-Match", "alice", "/tasks.md", "0", "", http.StatusPreconditionRequired},
+		{"stale ETag", "alice", "/tasks.md", "0", stale, http.StatusConflict},
+		{"bad index", "alice", "/tasks.md", "x", tag, http.StatusBadRequest},
+		{"index out of range", "alice", "/tasks.md", "3", tag, http.StatusBadRequest},
+		{"negative index", "alice", "/tasks.md", "-1", tag, http.StatusBadRequest},
+		{"not found", "alice", "/none.md", "0", tag, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		if w := postToggle(tmpv, c.page, c.index, c.user, c.tag); w.Code != c.code {
+			t.Errorf("%s: got %d, want %d", c.name, w.Code, c.code)
+		}
+	}
+	if bin, _ := os.ReadFile(file); string(bin) != src {
+		t.Fatalf("rejected requests changed the file: %q", bin)
+	}
+
+	w := postToggle(tmpv, "/tasks.md", "2", "alice", tag)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got %d, want %d: %s", w.Code, http.StatusNoContent, w.Body)
+	}
+	want := strings.Replace(src, "- [ ] three", "- [x] three", 1)
+	if bin, _ := os.ReadFile(file); string(bin) != want {
+		t.Fatalf("got %q, want %q", bin, want)
+	}
+	if fi, err := os.Stat(file); err != nil || fi.Mode().Perm() != 0o640 {
+		t.Errorf("got mode %v, %v", fi.Mode(), err)
+	}
+
+	new_tag := w.Header().Get("Etag")
+	if new_tag == "" || new_tag == tag || new_tag != taskEtag(t, tmpv, "/tasks.md", "alice") {
+		t.Fatalf("got Etag %q", new_tag)
+	}
+	if w := postToggle(tmpv, "/tasks.md", "1", "alice", tag); w.Code != http.StatusConflict {
+		t.Errorf("old ETag: got %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	if w := postToggle(tmpv, "/tasks.md", "1", "alice", new_tag); w.Code != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusNoContent)
+	}
+	want = strings.Replace(want, "- [x] two", "- [ ] two", 1)
+	if bin, _ := os.ReadFile(file); string(bin) != want {
+		t.Fatalf("got %q, want %q", bin, want)
+	}
+}
+
+func TestTaskToggleSymlink(t *testing.T) {
+	tmpv, doc_dir := newTaskTestView(t)
+
+	target := filepath.Join(doc_dir, "real.md")
+	if err := os.WriteFile(target, []byte("- [ ] task\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(doc_dir, "link.md")
+	if err := os.Symlink("real.md", link); err != nil {
+		t.Skip(err)
+	}
+
+	w := postToggle(tmpv, "/link.md", "0", "alice", taskEtag(t, tmpv, "/link.md", "alice"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("link was replaced: %v, %v", fi.Mode(), err)
+	}
+	if bin, _ := os.ReadFile(target); string(bin) != "- [x] task\n" {
+		t.Fatalf("got %q", bin)
+	}
+}
+
+func TestWriteFileAtomicRename(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(file, []byte("old text\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	old_fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old_f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old_f.Close()
+
+	if err := writeFileAtomic(file, []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	new_fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(old_fi, new_fi) {
+		t.Error("file is rewritten in place")
+	}
+	if new_fi.Mode().Perm() != 0o640 {
+		t.Errorf("got mode %v", new_fi.Mode().Perm())
+	}
+
+	old_bin, err := io.ReadAll(old_f)
+	if err != nil || string(old_bin) != "old text\n" {
+		t.Errorf("old file: got %q, %v", old_bin, err)
+	}
+	if bin, err := os.ReadFile(file); err != nil || string(bin) != "new\n" {
+		t.Errorf("new file: got %q, %v", bin, err)
+	}
+
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ents) != 1 {
+		t.Errorf("temporary file is left: %v", ents)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package tmplview
+
+import (
+	"os"
+	"syscall"
+)
+
+func chownAs(f *os.File, fi os.FileInfo) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if int(st.Uid) == os.Getuid() && int(st.Gid) == os.Getgid() {
+		return nil
+	}
+
+	return f.Chown(int(st.Uid), int(st.Gid))
+}
@@ -7,10 +7,12 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/l4go/rpath"
+	"github.com/l4go/unifs"
 
 	"github.com/1f408/cats_eeds/authz"
 	"github.com/1f408/cats_eeds/internal/ftype"
@@ -18,6 +20,7 @@ import (
 	"github.com/1f408/cats_eeds/upath"
 	"github.com/1f408/cats_eeds/view/internal/dirview"
 	"github.com/1f408/cats_eeds/view/internal/mtable"
+	"github.com/1f408/cats_eeds/view/internal/pcache"
 	"github.com/1f408/cats_eeds/view/internal/tmplext"
 )
 
@@ -74,6 +77,12 @@ type TmplView struct {
 
 	TextViewMode string
 
+	TaskTogglePath  string
+	TaskToggleAuthz string
+	task_mu         sync.Mutex
+
+	RenderCache *pcache.Cache
+
 	CatUiConfigPath upath.UPath
 	CatUiConfigExt  string
 	CatUiTmplName   string
@@ -106,6 +115,8 @@ func newTmplViewDefault() *TmplView {
 
 	tmpv.TextViewMode = "html"
 
+	tmpv.TaskToggleAuthz = "@"
+
 	tmpv.CatUiConfigExt = "ui"
 
 	return tmpv
@@ -258,6 +269,38 @@ func NewTmplView(cfg *TmplViewConfig) (*TmplView, error) {
 		return nil, new_err("Bad text view mode: %s", tmpv.TextViewMode)
 	}
 
+	if cfg.Tmpl.TaskTogglePath != "" {
+		tmpv.TaskTogglePath = cfg.Tmpl.TaskTogglePath
+		if rpath.Clean(tmpv.TaskTogglePath) != tmpv.TaskTogglePath || !rpath.IsAbs(tmpv.TaskTogglePath) ||
+			rpath.IsDir(tmpv.TaskTogglePath) {
+			return nil, new_err("Bad task toggle path: %s", tmpv.TaskTogglePath)
+		}
+	}
+	if cfg.Tmpl.TaskToggleAuthz != "" {
+		tmpv.TaskToggleAuthz = cfg.Tmpl.TaskToggleAuthz
+	}
+	if !authz.VerifyAuthzType(tmpv.TaskToggleAuthz, false) {
+		return nil, new_err("Bad task toggle authz: %s", tmpv.TaskToggleAuthz)
+	}
+
+	if cfg.Tmpl.RenderCacheSize < 0 {
+		return nil, new_err("Bad render cache size: %d", cfg.Tmpl.RenderCacheSize)
+	}
+	if cfg.Tmpl.RenderCacheSize > 0 {
+		disk_dir := ""
+		if !cfg.Tmpl.RenderCacheDir.IsZero() {
+			os_dir, err := unifs.ToOSPath(cfg.Tmpl.RenderCacheDir.String())
+			if err != nil {
+				return nil, new_err("Bad render cache directory: %s", cfg.Tmpl.RenderCacheDir.String())
+			}
+			if fi, err := os.Stat(os_dir); err != nil || !fi.IsDir() {
+				return nil, new_err("Not found render cache directory: %s", cfg.Tmpl.RenderCacheDir.String())
+			}
+			disk_dir = os_dir
+		}
+		tmpv.RenderCache = pcache.New(tmpv.SystemFS, cfg.Tmpl.RenderCacheSize, disk_dir)
+	}
+
 	if !cfg.Tmpl.CatUiConfigPath.IsZero() {
 		tmpv.CatUiConfigPath = cfg.Tmpl.CatUiConfigPath
 	}
@@ -277,9 +320,10 @@ func NewTmplView(cfg *TmplViewConfig) (*TmplView, error) {
 
 	tmpv.OriginTmpl = template.New("")
 	tmpl_funcs := template.FuncMap{
-		"in_group": func(grp string) bool { return false },
-		"in_user":  func() bool { return false },
-		"cat_ui":   tmpv.CatUi,
+		"in_group":    func(grp string) bool { return false },
+		"in_user":     func() bool { return false },
+		"cat_ui":      tmpv.CatUi,
+		"task_toggle": tmpv.taskToggleScript,
 	}
 	tmplext.AddDefaultFunc(tmpl_funcs, tmpv.SystemFS, tmpv.SvgIconPath)
 	tmpv.OriginTmpl = tmpv.OriginTmpl.Funcs(tmpl_funcs)
@@ -310,6 +354,9 @@ func NewTmplView(cfg *TmplViewConfig) (*TmplView, error) {
 	tmpv.MarkdownConfig = cfg.Tmpl.MarkdownConfig.Value
 	tmpv.CustomPageConfig = cfg.Tmpl.CustomPageConfig.Value
 	tmpv.PrintPaperMapping = tmpv.CustomPageConfig.PrintPaper.Mapping.Value
+	if tmpv.MarkdownConfig.Extension.TaskList {
+		tmpv.DirViewStamp.SetTaskCounter(tmpv.countTasks)
+	}
 
 	if cfg.Tmpl.ThemeStyle != "" {
 		tmpv.ThemeStyle = cfg.Tmpl.ThemeStyle